@@ -1,19 +1,21 @@
 package cmd
 
 import (
+	"crypto"
 	"fmt"
+	"io"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/openpgp"
 
-	"github.com/spachava753/kpkg/pkg/download"
+	"github.com/spachava753/kpkg/pkg/config"
 	"github.com/spachava753/kpkg/pkg/tool"
 )
 
-func MakeGetBinarySubCmds(
-	basePath string, parent *cobra.Command, tools []tool.Binary,
-	f download.FileFetcher, windows bool,
-) {
+func MakeGetBinarySubCmds(env *Env, parent *cobra.Command, tools []tool.Binary) {
 	for _, t := range tools {
 		func(t tool.Binary) {
 			parent.AddCommand(
@@ -22,7 +24,8 @@ func MakeGetBinarySubCmds(
 					Short: t.ShortDesc(),
 					Long:  t.LongDesc(),
 					RunE: func(cmd *cobra.Command, args []string) error {
-						v := "latest"
+						cfg := env.Config()
+						v := cfg.GetString(config.ToolKey(t.Name(), config.ToolVersion))
 						if len(args) != 0 {
 							v = args[0]
 						}
@@ -30,22 +33,90 @@ func MakeGetBinarySubCmds(
 						if err != nil {
 							return err
 						}
-						max, err := cmd.Flags().GetUint(CliMaxVersionsInstallFlag)
+						skipChecksum, err := cmd.Flags().GetBool(CliSkipChecksumFlag)
 						if err != nil {
 							return err
 						}
+						keyringPath, err := cmd.Flags().GetString(CliKeyringFlag)
+						if err != nil {
+							return err
+						}
+						var keyring openpgp.EntityList
+						if keyringPath != "" {
+							keyring, err = tool.LoadKeyring(keyringPath)
+							if err != nil {
+								return err
+							}
+						}
+						cosignKeyPath := cfg.GetString(config.ToolKey(t.Name(), config.ToolCosignKey))
+						if cmd.Flags().Changed(CliCosignKeyFlag) {
+							if cosignKeyPath, err = cmd.Flags().GetString(CliCosignKeyFlag); err != nil {
+								return err
+							}
+						}
+						var publicKey crypto.PublicKey
+						if cosignKeyPath != "" {
+							publicKey, err = tool.LoadPublicKey(cosignKeyPath)
+							if err != nil {
+								return err
+							}
+						}
+						fromFile, err := cmd.Flags().GetString(CliFromFileFlag)
+						if err != nil {
+							return err
+						}
+						dryRun, err := cmd.Flags().GetBool(CliDryRunFlag)
+						if err != nil {
+							return err
+						}
+						rewriter, err := env.Rewriter()
+						if err != nil {
+							return err
+						}
+						basePath, err := env.Root()
+						if err != nil {
+							return err
+						}
+						f, err := env.FileFetcher()
+						if err != nil {
+							return err
+						}
+						u, err := env.Unpacker()
+						if err != nil {
+							return err
+						}
+						opts := env.InstallOptions()
+						opts.FromFile = fromFile
+						opts.Force = force
+						opts.SkipChecksum = skipChecksum
+						opts.Keyring = keyring
+						opts.PublicKey = publicKey
+						opts.Rewriter = rewriter
+						opts.DryRun = dryRun
+						if fromFile == "" {
+							if opts.Versions, err = env.versionSource(cmd); err != nil {
+								return err
+							}
+						}
+
+						ctx, cancel := env.Context(cmd)
+						defer cancel()
 						p, e := tool.Install(
+							ctx,
 							basePath,
 							v,
-							force,
-							windows,
-							max,
 							t,
 							f,
+							u,
+							opts,
 						)
 						if e != nil {
 							return e
 						}
+						if dryRun {
+							cmd.Printf("binary would be installed at path %s\n", p)
+							return nil
+						}
 						cmd.Printf("binary installed at path %s\n", p)
 						return nil
 					},
@@ -55,9 +126,7 @@ func MakeGetBinarySubCmds(
 	}
 }
 
-func MakeListBinarySubCmds(
-	parent *cobra.Command, tools []tool.Binary, basePath string,
-) {
+func MakeListBinarySubCmds(env *Env, parent *cobra.Command, tools []tool.Binary) {
 	for _, t := range tools {
 		func(t tool.Binary) {
 			parent.AddCommand(
@@ -70,7 +139,12 @@ func MakeListBinarySubCmds(
 						if err != nil {
 							return err
 						}
-						max, err := cmd.Flags().GetUint(CliMaxVersionsInstallFlag)
+						details, err := cmd.Flags().GetBool(CliDetailsFlag)
+						if err != nil {
+							return err
+						}
+
+						basePath, err := env.Root()
 						if err != nil {
 							return err
 						}
@@ -82,11 +156,25 @@ func MakeListBinarySubCmds(
 							if err != nil {
 								return err
 							}
+							if details {
+								return printReceipts(
+									cmd.OutOrStdout(), basePath, cmd.Name(),
+									versions,
+								)
+							}
 							fmt.Println(strings.Join(versions, "\n"))
 							return nil
 						}
 
-						versions, err := t.Versions(max)
+						ctx, cancel := env.Context(cmd)
+						defer cancel()
+						source, err := env.versionSource(cmd)
+						if err != nil {
+							return err
+						}
+						versions, err := source.Versions(
+							ctx, t, env.Config().GetUint(config.MaxVersions),
+						)
 						if err != nil {
 							return err
 						}
@@ -98,3 +186,30 @@ func MakeListBinarySubCmds(
 		}(t)
 	}
 }
+
+// printReceipts prints the receipts of the installed versions of a binary
+func printReceipts(
+	out io.Writer, basePath, binary string, versions []string,
+) error {
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+	for _, v := range versions {
+		r, err := tool.ReadReceipt(basePath, binary, v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, v)
+		if r == nil {
+			fmt.Fprintln(w, "  no receipt, installed before receipts were recorded")
+			continue
+		}
+		fmt.Fprintf(w, "  url:\t%s\n", r.Url)
+		fmt.Fprintf(w, "  artifact sha256:\t%s\n", r.ArtifactSha256)
+		fmt.Fprintf(w, "  binary sha256:\t%s\n", r.BinarySha256)
+		fmt.Fprintf(w, "  size:\t%d\n", r.Size)
+		fmt.Fprintf(w, "  installed at:\t%s\n", r.InstalledAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "  kpkg version:\t%s\n", r.KpkgVersion)
+		fmt.Fprintf(w, "  os/arch:\t%s/%s\n", r.Os, r.Arch)
+		fmt.Fprintf(w, "  forced:\t%t\n", r.Force)
+	}
+	return w.Flush()
+}
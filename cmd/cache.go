@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/download"
+)
+
+const CliOlderThanFlag = "older-than"
+
+func MakeCache(env *Env) *cobra.Command {
+	var cacheCmd = &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of downloaded artifacts",
+		Long: `Manage the cache of downloaded artifacts. Artifacts are cached when they are downloaded,
+so that re-installing a version does not download it again. Once the cache grows past its max size,
+set with the cache.maxSize setting, the least recently used artifacts are evicted`,
+		Example: `
+Show cached artifacts:
+kpkg cache list
+
+Remove all cached artifacts:
+kpkg cache clean
+
+Remove artifacts that were not used in the last week:
+kpkg cache prune --older-than 168h
+`,
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List cached artifacts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, err := env.Cache()
+			if err != nil {
+				return err
+			}
+			entries, err := cache.List()
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "URL\tSIZE\tLAST USED")
+			var total int64
+			for _, e := range entries {
+				fmt.Fprintf(
+					w, "%s\t%s\t%s\n", e.Url, download.FormatSize(e.Size),
+					e.LastUsed.Format(time.RFC3339),
+				)
+				total += e.Size
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			cmd.Printf(
+				"%d artifacts in %s, %s\n", len(entries), cache.Dir(),
+				download.FormatSize(total),
+			)
+			return nil
+		},
+	}
+
+	cleanCmd := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove all cached artifacts",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, err := env.Cache()
+			if err != nil {
+				return err
+			}
+			if err := cache.Clean(); err != nil {
+				return err
+			}
+			cmd.Println("cache cleaned")
+			return nil
+		},
+	}
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove cached artifacts that were not used recently",
+		Long: `Remove cached artifacts that were not used within the duration passed to --older-than.
+The least recently used artifacts are also evicted until the cache fits in its max size`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cache, err := env.Cache()
+			if err != nil {
+				return err
+			}
+			olderThan, err := cmd.Flags().GetDuration(CliOlderThanFlag)
+			if err != nil {
+				return err
+			}
+			pruned, err := cache.Prune(olderThan)
+			if err != nil {
+				return err
+			}
+			evicted, err := cache.Evict()
+			if err != nil {
+				return err
+			}
+			for _, e := range append(pruned, evicted...) {
+				cmd.Printf("removed %s\n", e.Url)
+			}
+			return nil
+		},
+	}
+	pruneCmd.Flags().Duration(
+		CliOlderThanFlag, 30*24*time.Hour,
+		"remove artifacts that were not used for longer than this",
+	)
+
+	cacheCmd.AddCommand(listCmd, cleanCmd, pruneCmd)
+	return cacheCmd
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/config"
+)
+
+func MakeConfig(env *Env) *cobra.Command {
+	var configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Show or change the settings of kpkg",
+		Long: `Show or change the settings of kpkg. A setting is taken from the first of these that sets it:
+a flag, an env var, the config file, or its default. The config file is config.yaml in the kpkg dir of
+$XDG_CONFIG_HOME, or ~/.config if it is not set. Set KPKG_CONFIG to use a different config file.
+Per tool settings are nested under tools, like tools.kubectl.version`,
+		Example: `
+Show all settings, and where they were set:
+kpkg config list
+
+Show the max number of versions to list:
+kpkg config get maxVersions
+
+Install kubectl 1.20.0 when no version is given:
+kpkg config set tools.kubectl.version 1.20.0
+
+Download from a mirror:
+kpkg config set mirrors.rewrite 'https://github.com/=>https://mirror.example.com/github/'
+`,
+	}
+
+	pathCmd := &cobra.Command{
+		Use:   "path",
+		Short: "Print the path of the config file",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.Println(env.Config().Path())
+			return nil
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all settings, and where they were set",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			values, err := env.Config().List()
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+			for _, v := range values {
+				value := v.String()
+				if s, err := config.Describe(v.Key); err == nil && s.Secret && value != "" {
+					value = "<hidden>"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, value, v.Source)
+			}
+			return w.Flush()
+		},
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print the value of a setting",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			v, err := env.Config().Get(args[0])
+			if err != nil {
+				return err
+			}
+			if v.List != nil {
+				if len(v.List) != 0 {
+					cmd.Println(strings.Join(v.List, "\n"))
+				}
+				return nil
+			}
+			cmd.Println(v.Value)
+			return nil
+		},
+	}
+
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>...",
+		Short: "Write a setting to the config file",
+		Long: `Write a setting to the config file. List settings, like mirrors.rewrite, take any number of values,
+which replace the values in the config file`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg := env.Config()
+			if err := cfg.Set(args[0], args[1:]...); err != nil {
+				return err
+			}
+			cmd.Printf("set %s in %s\n", args[0], cfg.Path())
+			return nil
+		},
+	}
+
+	configCmd.AddCommand(pathCmd, listCmd, getCmd, setCmd)
+	return configCmd
+}
@@ -0,0 +1,400 @@
+package cmd
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/download"
+	"github.com/spachava753/kpkg/pkg/index"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+// flagSettings maps flags to the settings that they override
+var flagSettings = map[string]string{
+	CliRootFlag:                config.Root,
+	CliMaxVersionsInstallFlag:  config.MaxVersions,
+	CliQuietFlag:               config.Quiet,
+	CliLockTimeoutFlag:         config.LockTimeout,
+	CliTimeoutFlag:             config.CommandTimeout,
+	CliConnectTimeoutFlag:      config.ConnectTimeout,
+	CliTLSHandshakeTimeoutFlag: config.TLSHandshakeTimeout,
+	CliReadTimeoutFlag:         config.ReadTimeout,
+	CliCABundleFlag:            config.CABundle,
+	CliProxyFlag:               config.Proxy,
+	CliInsecureSkipVerifyFlag:  config.InsecureSkipVerify,
+	CliGithubApiFlag:           config.GithubApiUrl,
+	CliRewriteFlag:             config.Rewrite,
+	CliRewriteRegexFlag:        config.RewriteRegex,
+	CliIndexTTLFlag:            config.IndexTTL,
+}
+
+// Env holds what commands share. The config is loaded by the root command once the flags are
+// parsed, since flags take precedence over it. Everything else is set up from the config the
+// first time a command needs it, so that commands like kpkg config don't touch the kpkg root
+type Env struct {
+	// KpkgVersion, Os and Arch describe the running kpkg, and are recorded in install receipts
+	KpkgVersion, Os, Arch string
+
+	config    *config.Config
+	progress  *download.TerminalReporter
+	transport *download.Transport
+	errOut    io.Writer
+	// out is where installs print what they are doing
+	out *os.File
+
+	dirs              config.Dirs
+	tmpPath           string
+	cache             *download.Cache
+	index             *index.Index
+	fetcher, unpacker download.FileFetcher
+}
+
+// load loads the config, overridden by the flags of the command, and applies the settings
+// that don't need the kpkg root
+func (e *Env) load(cmd *cobra.Command) error {
+	return e.loadConfig(cmd.Flags(), cmd.ErrOrStderr())
+}
+
+// loadConfig loads the config, overridden by the flags if there are any. Shims load the config
+// without flags, since their args are passed on to the binary
+func (e *Env) loadConfig(flags *pflag.FlagSet, errOut io.Writer) error {
+	path, err := config.DefaultPath()
+	if err != nil {
+		return err
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if flags != nil {
+		if err := bindFlags(flags, cfg); err != nil {
+			return err
+		}
+	}
+	e.config = cfg
+	e.errOut = errOut
+
+	e.progress.Quiet = cfg.GetBool(config.Quiet)
+	tool.LockTimeout = cfg.GetDuration(config.LockTimeout)
+
+	transportOpts := download.TransportOptions{
+		ConnectTimeout:      cfg.GetDuration(config.ConnectTimeout),
+		TLSHandshakeTimeout: cfg.GetDuration(config.TLSHandshakeTimeout),
+		ReadTimeout:         cfg.GetDuration(config.ReadTimeout),
+		CABundle:            cfg.GetString(config.CABundle),
+		Proxy:               cfg.GetString(config.Proxy),
+		InsecureSkipVerify:  cfg.GetBool(config.InsecureSkipVerify),
+	}
+	if transportOpts.InsecureSkipVerify {
+		fmt.Fprintln(
+			errOut, "warning: TLS certificates are not verified, downloads can be tampered with",
+		)
+	}
+	return e.transport.Configure(transportOpts)
+}
+
+// bindFlags overrides the settings of the flags that were set
+func bindFlags(flags *pflag.FlagSet, cfg *config.Config) error {
+	for name, key := range flagSettings {
+		f := flags.Lookup(name)
+		if f == nil || !f.Changed {
+			continue
+		}
+		values := []string{f.Value.String()}
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			values = s.GetSlice()
+		}
+		if err := cfg.SetFlag(key, values...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Config returns the config, which is loaded once the flags are parsed
+func (e *Env) Config() *config.Config {
+	return e.config
+}
+
+// Dirs returns the dirs that kpkg keeps its state in, creating the root if it does not exist
+func (e *Env) Dirs() (config.Dirs, error) {
+	if e.dirs.Root != "" {
+		return e.dirs, nil
+	}
+	dirs, err := e.resolveDirs()
+	if err != nil {
+		return config.Dirs{}, err
+	}
+	if _, err := config.CreateRoot(dirs.Root); err != nil {
+		return config.Dirs{}, err
+	}
+	e.dirs = dirs
+	return dirs, nil
+}
+
+// resolveDirs returns the dirs of the root layout if the root is set, or else the XDG dirs.
+// An existing ~/.kpkg is migrated to the XDG dirs the first time they are used
+func (e *Env) resolveDirs() (config.Dirs, error) {
+	if root := e.config.GetString(config.Root); root != "" {
+		return config.RootDirs(root), nil
+	}
+	dirs, err := config.XDGDirs()
+	if err != nil {
+		return config.Dirs{}, err
+	}
+	legacyRoot, err := config.LegacyRoot()
+	if err != nil {
+		return config.Dirs{}, err
+	}
+	if info, err := os.Lstat(legacyRoot); err != nil || !info.IsDir() {
+		return dirs, nil
+	}
+	if !config.NeedsMigration(legacyRoot, dirs) {
+		fmt.Fprintf(
+			e.errOut,
+			"warning: both %s and %s exist, using %s. Set KPKG_ROOT to use %s instead\n",
+			legacyRoot, dirs.Root, dirs.Root, legacyRoot,
+		)
+		return dirs, nil
+	}
+
+	// wait for other kpkg processes to finish modifying binaries in the legacy root,
+	// or to finish migrating it
+	unlock, err := tool.LockRoot(context.Background(), legacyRoot)
+	if err != nil {
+		return config.Dirs{}, err
+	}
+	defer unlock()
+	if !config.NeedsMigration(legacyRoot, dirs) {
+		return dirs, nil
+	}
+	err = config.Migrate(legacyRoot, dirs)
+	if config.NeedsMigration(legacyRoot, dirs) {
+		// nothing was moved, so the legacy root is still whole
+		fmt.Fprintf(e.errOut, "warning: %s, still using %s\n", err, legacyRoot)
+		return config.RootDirs(legacyRoot), nil
+	}
+	if err != nil {
+		fmt.Fprintf(e.errOut, "warning: could not finish migrating %s: %s\n", legacyRoot, err)
+	}
+	fmt.Fprintf(
+		e.errOut,
+		"moved binaries from %s to %s. Update your PATH to include %s instead of %s\n",
+		legacyRoot, dirs.Root, filepath.Join(dirs.Root, "bin"), filepath.Join(legacyRoot, "bin"),
+	)
+	return dirs, nil
+}
+
+// Root returns the dir that binaries are installed in
+func (e *Env) Root() (string, error) {
+	dirs, err := e.Dirs()
+	if err != nil {
+		return "", err
+	}
+	return dirs.Root, nil
+}
+
+// Cache returns the cache of downloaded artifacts
+func (e *Env) Cache() (*download.Cache, error) {
+	if e.cache != nil {
+		return e.cache, nil
+	}
+	dirs, err := e.Dirs()
+	if err != nil {
+		return nil, err
+	}
+	cache, err := download.MakeCache(dirs.Cache, e.config.GetSize(config.CacheMaxSize))
+	if err != nil {
+		return nil, err
+	}
+	e.cache = cache
+	return cache, nil
+}
+
+// Index returns the local index of versions. Versions are listed through the
+// index, so the GitHub client is configured along with it. Responses of the GitHub API are
+// stored next to the index, so that versions that did not change are revalidated instead of
+// downloaded again
+func (e *Env) Index() (*index.Index, error) {
+	if e.index != nil {
+		return e.index, nil
+	}
+	dirs, err := e.Dirs()
+	if err != nil {
+		return nil, err
+	}
+	githubTransport, err := index.MakeRevalidatingTransport(
+		filepath.Join(dirs.Index, "http"), e.transport,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := tool.ConfigureGithub(
+		tool.GithubOptions{
+			Token:     e.config.GetString(config.GithubToken),
+			Transport: githubTransport,
+			BaseUrl:   e.config.GetString(config.GithubApiUrl),
+		},
+	); err != nil {
+		return nil, err
+	}
+	idx, err := index.MakeIndex(dirs.Index, e.config.GetDuration(config.IndexTTL), e.errOut)
+	if err != nil {
+		return nil, err
+	}
+	e.index = idx
+	return idx, nil
+}
+
+// FileFetcher returns the file fetcher that downloads artifacts into a temp dir,
+// which is removed by Close
+func (e *Env) FileFetcher() (download.FileFetcher, error) {
+	if e.fetcher != nil {
+		return e.fetcher, nil
+	}
+	cache, err := e.Cache()
+	if err != nil {
+		return nil, err
+	}
+	if e.tmpPath == "" {
+		tmpPath, err := download.MakeTempDir()
+		if err != nil {
+			return nil, err
+		}
+		e.tmpPath = tmpPath
+	}
+	fetcher, err := download.InitFileFetcher(
+		e.tmpPath, e.transport, e.config.GetUint(config.Retries), cache, e.progress, e.out,
+	)
+	if err != nil {
+		return nil, err
+	}
+	e.fetcher = fetcher
+	return fetcher, nil
+}
+
+// Unpacker returns the file fetcher that unpacks downloaded artifacts
+func (e *Env) Unpacker() (download.FileFetcher, error) {
+	if e.unpacker != nil {
+		return e.unpacker, nil
+	}
+	unpacker, err := download.InitUnpacker(e.progress, e.out)
+	if err != nil {
+		return nil, err
+	}
+	e.unpacker = unpacker
+	return unpacker, nil
+}
+
+// Rewriter returns the rewriter of download urls, or nil if there are no rewrite rules
+func (e *Env) Rewriter() (*download.Rewriter, error) {
+	var rules []download.RewriteRule
+	for _, key := range []string{config.Rewrite, config.RewriteRegex} {
+		for _, v := range e.config.GetStringSlice(key) {
+			rule, err := download.ParseRewriteRule(v, key == config.RewriteRegex)
+			if err != nil {
+				return nil, err
+			}
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	return download.MakeRewriter(rules...)
+}
+
+// InstallOptions returns the install options that don't depend on the command
+func (e *Env) InstallOptions() tool.InstallOptions {
+	shim, _ := executablePath()
+	return tool.InstallOptions{
+		Windows:     e.Os == "windows",
+		KpkgVersion: e.KpkgVersion,
+		Os:          e.Os,
+		Arch:        e.Arch,
+		Max:         e.config.GetUint(config.MaxVersions),
+		Shim:        shim,
+		Out:         e.out,
+	}
+}
+
+// executablePath returns the path of the running kpkg executable, with symlinks resolved.
+// Shims link to it, and kpkg self-update replaces it
+func executablePath() (string, error) {
+	p, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(p)
+}
+
+// CosignKey returns the public key that the config trusts to sign the artifacts of the binary,
+// or nil if there is none
+func (e *Env) CosignKey(binary string) (crypto.PublicKey, error) {
+	p := e.config.GetString(config.ToolKey(binary, config.ToolCosignKey))
+	if p == "" {
+		return nil, nil
+	}
+	return tool.LoadPublicKey(p)
+}
+
+// Context returns the context of the command, bounded by the command timeout.
+// The returned func releases the resources of the context, and must be called
+func (e *Env) Context(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout := e.config.GetDuration(config.CommandTimeout); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// outputTo prints what installs are doing, and the progress of downloads and extractions, to out
+// instead of stdout. Commands whose stdout belongs to something else, like a binary they run,
+// print to stderr
+func (e *Env) outputTo(out *os.File) {
+	if e.out == out {
+		return
+	}
+	e.out = out
+	quiet := e.progress.Quiet
+	*e.progress = *download.MakeTerminalReporter(out)
+	e.progress.Quiet = quiet
+	// the file fetchers log to the output they were created with, so they are created again
+	e.fetcher, e.unpacker = nil, nil
+}
+
+// Close removes the temp dir that artifacts were downloaded into
+func (e *Env) Close() error {
+	if e.tmpPath == "" {
+		return nil
+	}
+	return os.RemoveAll(e.tmpPath)
+}
+
+// MakeEnv returns the env of a kpkg release, which reports progress to stdout
+func MakeEnv(kpkgVersion, goos, goarch string) (*Env, error) {
+	transport, err := download.MakeTransport(download.DefaultTransportOptions())
+	if err != nil {
+		return nil, err
+	}
+	return &Env{
+		KpkgVersion: kpkgVersion,
+		Os:          goos,
+		Arch:        goarch,
+		progress:    download.MakeTerminalReporter(os.Stdout),
+		errOut:      os.Stderr,
+		out:         os.Stdout,
+		transport:   transport,
+	}, nil
+}
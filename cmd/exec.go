@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver"
+	"github.com/spf13/cobra"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+const CliNoInstallFlag = "no-install"
+
+func MakeExec(env *Env, tools []tool.Binary) *cobra.Command {
+	var execCmd = &cobra.Command{
+		Use:   "exec <binary>@<version> [-- args...]",
+		Short: "Run a version of a binary, without linking it",
+		Long: `Run a version of a binary with the given args, without linking it, so the linked version stays as it is.
+A version that is not installed is installed first, unless --no-install is set. The binary gets the stdin,
+stdout and stderr of kpkg, and kpkg exits with its exit code. The output of installing goes to stderr,
+so the output of the binary can be piped`,
+		Example: `
+Compare the output of two versions of helm:
+diff <(kpkg exec helm@3.4.2 -- template ./chart) <(kpkg exec helm@3.5.0 -- template ./chart)
+
+Run the latest version of kubectl:
+kpkg exec kubectl@latest -- version --client
+
+Fail instead of installing a version that is not installed:
+kpkg exec kubectl@1.20.0 --no-install -- version --client
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			// the args of the binary follow --, so that they are not parsed as flags of kpkg
+			if dash := cmd.ArgsLenAtDash(); dash != 1 && (dash != -1 || len(args) != 1) {
+				return fmt.Errorf("expected a binary@version, followed by -- and the args of the binary")
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			noInstall, err := cmd.Flags().GetBool(CliNoInstallFlag)
+			if err != nil {
+				return err
+			}
+			b, version, err := parseToolVersion(tools, args[0])
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := env.Context(cmd)
+			binaryPath, err := env.installUnlinked(ctx, cmd, b, version, "", !noInstall)
+			cancel()
+			if err != nil {
+				return err
+			}
+			// the binary replaces kpkg, so deferred cleanup would not run
+			if err := env.Close(); err != nil {
+				return err
+			}
+			err = execBinary(binaryPath, args[1:])
+			if _, ok := err.(*kpkgerr.ExitErr); ok {
+				// the binary already reported what went wrong
+				cmd.SilenceErrors, cmd.SilenceUsage = true, true
+			}
+			return err
+		},
+	}
+	execCmd.Flags().Bool(
+		CliNoInstallFlag, false, "fail if the version is not installed, instead of installing it",
+	)
+	InstallRefreshFlag(execCmd)
+	return execCmd
+}
+
+// runChild runs c with the stdio of kpkg, and returns its exit code as an ExitErr if it fails.
+// The child gets the interrupts of the terminal too, and decides when to exit, so kpkg ignores
+// them until it does
+func runChild(c *exec.Cmd) error {
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	signal.Ignore(os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+	err := c.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return &kpkgerr.ExitErr{Code: exitErr.ExitCode()}
+	}
+	return err
+}
+
+// normalizeVersion returns the semver form of the version, since versions are installed in the
+// dirs of their semver form, so v1.20.0 is 1.20.0. Versions that are not semver are returned as they are
+func normalizeVersion(version string) string {
+	if v, err := semver.NewVersion(version); err == nil {
+		return v.String()
+	}
+	return version
+}
+
+// parseToolVersion parses a binary and its version, written as binary@version
+func parseToolVersion(tools []tool.Binary, s string) (tool.Binary, string, error) {
+	i := strings.LastIndex(s, "@")
+	if i <= 0 || i == len(s)-1 {
+		return nil, "", fmt.Errorf("invalid binary %s, expected binary@version, like kubectl@1.20.0", s)
+	}
+	name, version := s[:i], s[i+1:]
+	b, ok := FindTool(tools, name)
+	if !ok {
+		return nil, "", fmt.Errorf("unknown binary %s", name)
+	}
+	return b, version, nil
+}
+
+// installUnlinked returns the path of the version of the binary, installing it without linking
+// it if it is not installed yet and install is true. The version may be latest. If sha256 is set,
+// it is the digest that the artifact must have if the version is installed
+func (e *Env) installUnlinked(
+	ctx context.Context, cmd *cobra.Command, b tool.Binary, version, sha256 string, install bool,
+) (string, error) {
+	// stdout belongs to the binary, so installing prints to stderr
+	e.outputTo(os.Stderr)
+	opts := e.InstallOptions()
+	binary := b.Name()
+	if opts.Windows {
+		binary += ".exe"
+	}
+	basePath, err := e.Root()
+	if err != nil {
+		return "", err
+	}
+	source, err := e.versionSource(cmd)
+	if err != nil {
+		return "", err
+	}
+	if version == "latest" {
+		versions, err := source.Versions(ctx, b, opts.Max)
+		if err != nil {
+			return "", err
+		}
+		if len(versions) == 0 {
+			return "", fmt.Errorf("no versions of %s found", b.Name())
+		}
+		version = versions[0]
+	}
+	version = normalizeVersion(version)
+	installed, err := tool.Installed(basePath, binary, version)
+	if err != nil {
+		return "", err
+	}
+	if installed {
+		return filepath.Join(basePath, binary, version, binary), nil
+	}
+	if !install {
+		return "", fmt.Errorf("%s %s is not installed", b.Name(), version)
+	}
+
+	f, err := e.FileFetcher()
+	if err != nil {
+		return "", err
+	}
+	u, err := e.Unpacker()
+	if err != nil {
+		return "", err
+	}
+	opts.NoLink = true
+	opts.Sha256 = sha256
+	opts.Versions = source
+	if opts.Rewriter, err = e.Rewriter(); err != nil {
+		return "", err
+	}
+	if opts.PublicKey, err = e.CosignKey(b.Name()); err != nil {
+		return "", err
+	}
+	return tool.Install(ctx, basePath, version, b, f, u, opts)
+}
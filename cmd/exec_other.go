@@ -0,0 +1,14 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package cmd
+
+import (
+	"os/exec"
+)
+
+// execBinary runs the binary at path, since the process of kpkg can't be replaced on this
+// platform, and returns its exit code as an ExitErr, see runChild
+func execBinary(path string, args []string) error {
+	return runChild(exec.Command(path, args...))
+}
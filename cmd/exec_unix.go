@@ -0,0 +1,15 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+)
+
+// execBinary replaces kpkg with the binary at path, so that the binary gets the signals,
+// stdio and exit code of kpkg. It only returns if the binary could not be run
+func execBinary(path string, args []string) error {
+	return syscall.Exec(path, append([]string{path}, args...), os.Environ())
+}
@@ -3,12 +3,30 @@ package cmd
 import "github.com/spf13/cobra"
 
 const CliForceInstallFlag = "force"
+const CliSkipChecksumFlag = "skip-checksum"
+const CliKeyringFlag = "keyring"
+const CliCosignKeyFlag = "cosign-key"
+const CliFromFileFlag = "from-file"
+const CliDryRunFlag = "dry-run"
 
 func MakeGet() *cobra.Command {
 	var getCmd = &cobra.Command{
 		Use:   "get",
 		Short: "Get or install a binary",
 		Long:  `Get or install a binary. By default, the latest version of the binary will be downloaded`,
+		Example: `
+Install the latest version of helm:
+kpkg get helm
+
+Install helm from an artifact that was already downloaded, without network access:
+kpkg get helm 3.5.2 --from-file ./helm-v3.5.2-linux-amd64.tar.gz
+
+Install helm from a dir of artifacts that were already downloaded:
+kpkg get helm 3.5.2 --from-file ./artifacts
+
+Show where helm would be downloaded from, after rewriting its url to a mirror:
+kpkg get helm --dry-run --rewrite 'https://get.helm.sh/=>https://mirror.example.com/helm/'
+`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if err := cobra.MaximumNArgs(1)(cmd, args); err != nil {
 				return err
@@ -20,6 +38,27 @@ func MakeGet() *cobra.Command {
 	getCmd.PersistentFlags().Bool(
 		CliForceInstallFlag, false, "force a re-install if already installed",
 	)
+	getCmd.PersistentFlags().Bool(
+		CliSkipChecksumFlag, false,
+		"skip verifying the checksum of the downloaded artifact",
+	)
+	getCmd.PersistentFlags().String(
+		CliKeyringFlag, "",
+		"path to an OpenPGP keyring trusted to sign checksum files, in place of the bundled keys",
+	)
+	getCmd.PersistentFlags().String(
+		CliCosignKeyFlag, "",
+		"path to a PEM encoded public key trusted to sign artifacts with cosign. Once set, a missing signature blocks the install",
+	)
+	getCmd.PersistentFlags().String(
+		CliFromFileFlag, "",
+		"install from a local artifact, or a dir of artifacts named like their download url, instead of downloading it",
+	)
+	getCmd.PersistentFlags().Bool(
+		CliDryRunFlag, false,
+		"only print the urls that would be downloaded, after rewriting them, without installing anything",
+	)
 	InstallMaxVersionsFlag(getCmd)
+	InstallRefreshFlag(getCmd)
 	return getCmd
 }
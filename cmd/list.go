@@ -11,8 +11,9 @@ import (
 
 const CliInstalledVersionsFlag = "installed"
 const CliInstalledVersionsShorthandFlag = "i"
+const CliDetailsFlag = "details"
 
-func MakeList(basePath string) *cobra.Command {
+func MakeList(env *Env) *cobra.Command {
 	var listCmd = &cobra.Command{
 		Use:   "list",
 		Short: "List versions of a specific binary",
@@ -26,6 +27,9 @@ kpkg list eksctl
 
 Show installed versions of a specific binary:
 kpkg list -i eksctl
+
+Show where the installed versions of a specific binary came from:
+kpkg list -i eksctl --details
 `,
 		Args: func(cmd *cobra.Command, args []string) error {
 			return cobra.NoArgs(cmd, args)
@@ -37,6 +41,10 @@ kpkg list -i eksctl
 			}
 
 			if locallyOnly {
+				basePath, err := env.Root()
+				if err != nil {
+					return err
+				}
 				binaries, err := tool.ListInstalled(basePath)
 				if err != nil {
 					return err
@@ -56,6 +64,11 @@ kpkg list -i eksctl
 		false,
 		"show only installed versions",
 	)
+	listCmd.PersistentFlags().Bool(
+		CliDetailsFlag, false,
+		"show the install receipt of each installed version",
+	)
 	InstallMaxVersionsFlag(listCmd)
+	InstallRefreshFlag(listCmd)
 	return listCmd
 }
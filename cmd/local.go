@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/pin"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+const CliUnsetFlag = "unset"
+
+func MakeLocal(env *Env, tools []tool.Binary) *cobra.Command {
+	var localCmd = &cobra.Command{
+		Use:   "local <binary> [version]",
+		Short: "Pin the version of a binary for the current dir",
+		Long: `Pin the version of a binary for the current dir and its subdirs, in the .kpkg-version file of
+the current dir. The nearest .kpkg-version file that pins a binary, looking from the current dir up to /,
+decides which version its shim runs. Pins only take effect once shims are enabled, see kpkg shims.
+Without a version, the version of the binary in effect for the current dir is printed`,
+		Example: `
+Use kubectl 1.20.0 in the current dir:
+kpkg local kubectl 1.20.0
+
+Show the version of kubectl used in the current dir:
+kpkg local kubectl
+
+Remove the pin of kubectl from the current dir:
+kpkg local kubectl --unset
+`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			unset, err := cmd.Flags().GetBool(CliUnsetFlag)
+			if err != nil {
+				return err
+			}
+			name := args[0]
+			if _, ok := FindTool(tools, name); !ok {
+				return fmt.Errorf("unknown binary %s", name)
+			}
+			binary := name
+			if env.Os == "windows" {
+				binary += ".exe"
+			}
+			dir, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			basePath, err := env.Root()
+			if err != nil {
+				return err
+			}
+			pinPath := filepath.Join(dir, pin.FileName)
+
+			switch {
+			case unset:
+				if len(args) == 2 {
+					return fmt.Errorf("--%s does not take a version", CliUnsetFlag)
+				}
+				if err := pin.Set(pinPath, name, ""); err != nil {
+					return err
+				}
+				cmd.Printf("removed the pin of %s from %s\n", name, pinPath)
+			case len(args) == 1:
+				version, p, err := pin.Find(dir, name)
+				if err != nil {
+					return err
+				}
+				if version != "" {
+					cmd.Printf("%s (pinned by %s)\n", version, p)
+					return nil
+				}
+				linked, err := tool.LinkedVersion(basePath, binary)
+				if err != nil {
+					return err
+				}
+				if linked == "" {
+					return fmt.Errorf("no version of %s is pinned or linked", name)
+				}
+				cmd.Printf("%s (linked)\n", linked)
+				return nil
+			default:
+				version := normalizeVersion(args[1])
+				installed, err := tool.Installed(basePath, binary, version)
+				if err != nil {
+					return err
+				}
+				if err := pin.Set(pinPath, name, version); err != nil {
+					return err
+				}
+				cmd.Printf("pinned %s %s in %s\n", name, version, pinPath)
+				if !installed {
+					cmd.PrintErrf(
+						"warning: %s %s is not installed, install it with kpkg get %s %s\n",
+						name, version, name, version,
+					)
+				}
+			}
+			if !tool.ShimsEnabled(basePath) {
+				cmd.PrintErrln("shims are disabled, pins take effect once they are enabled with kpkg shims enable")
+			}
+			return nil
+		},
+	}
+	localCmd.Flags().Bool(CliUnsetFlag, false, "remove the pin of the binary from the current dir")
+	return localCmd
+}
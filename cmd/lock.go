@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/manifest"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+const CliPlatformFlag = "platform"
+
+func MakeLock(env *Env, tools []tool.Binary) *cobra.Command {
+	var lockCmd = &cobra.Command{
+		Use:   "lock",
+		Short: "Resolve the manifest to exact versions in a lockfile",
+		Long: `Resolve the versions of the binaries in the manifest, kpkg.yaml by default, to exact versions,
+and record them in kpkg.lock next to it, along with the sha256 digests of their artifacts. Artifacts are
+downloaded and verified in order to hash them, except for versions that did not change, whose digests
+are kept. By default, artifacts are locked for the current platform and the platforms already in the lockfile`,
+		Example: `
+Lock the binaries of kpkg.yaml:
+kpkg lock
+
+Lock for the platforms of a team, instead of the current one:
+kpkg lock --platform linux/amd64 --platform darwin/amd64 --platform darwin/arm64
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, previous, lockPath, err := loadManifest(cmd)
+			if err != nil {
+				return err
+			}
+			current := manifest.Platform(env.Os, env.Arch)
+			platforms, err := cmd.Flags().GetStringSlice(CliPlatformFlag)
+			if err != nil {
+				return err
+			}
+			if len(platforms) == 0 {
+				platforms = []string{current}
+				for _, p := range previous.Platforms() {
+					if p != current {
+						platforms = append(platforms, p)
+					}
+				}
+			}
+			platformTools := map[string][]tool.Binary{current: tools}
+			for _, p := range platforms {
+				parts := strings.Split(p, "/")
+				if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+					return fmt.Errorf("invalid platform %s, expected os/arch", p)
+				}
+				if _, ok := platformTools[p]; !ok {
+					platformTools[p] = GetTools(parts[0], parts[1])
+				}
+			}
+
+			source, err := env.versionSource(cmd)
+			if err != nil {
+				return err
+			}
+			f, err := env.FileFetcher()
+			if err != nil {
+				return err
+			}
+			rewriter, err := env.Rewriter()
+			if err != nil {
+				return err
+			}
+			opts := env.InstallOptions()
+			opts.Rewriter = rewriter
+			max := env.Config().GetUint(config.MaxVersions)
+			ctx, cancel := env.Context(cmd)
+			defer cancel()
+
+			lock := &manifest.Lock{Tools: map[string]manifest.LockedTool{}}
+			for _, name := range m.Names() {
+				constraint := m.Tools[name]
+				b, ok := FindTool(tools, name)
+				if !ok {
+					return fmt.Errorf("unknown binary %s in the manifest", name)
+				}
+				version, err := resolveConstraint(ctx, source, b, constraint, max)
+				if err != nil {
+					return fmt.Errorf("could not resolve %s %s: %w", name, constraint, err)
+				}
+				if opts.PublicKey, err = env.CosignKey(name); err != nil {
+					return err
+				}
+
+				locked := manifest.LockedTool{
+					Constraint: constraint,
+					Version:    version,
+					Artifacts:  map[string]manifest.LockedArtifact{},
+				}
+				was := previous.Tools[name]
+				for _, p := range platforms {
+					if a, ok := was.Artifacts[p]; ok && was.Version == version {
+						locked.Artifacts[p] = a
+						continue
+					}
+					pb, ok := FindTool(platformTools[p], name)
+					if !ok {
+						return fmt.Errorf("unknown binary %s for %s", name, p)
+					}
+					cmd.Printf("hashing %s %s for %s\n", name, version, p)
+					a, err := tool.ResolveArtifact(ctx, version, pb, f, opts)
+					if err != nil {
+						return fmt.Errorf("could not lock %s %s for %s: %w", name, version, p, err)
+					}
+					locked.Artifacts[p] = manifest.LockedArtifact{Url: a.Url, Sha256: a.Sha256}
+				}
+				lock.Tools[name] = locked
+				if was.Version != "" && was.Version != version {
+					cmd.Printf("locked %s %s, was %s\n", name, version, was.Version)
+				} else {
+					cmd.Printf("locked %s %s\n", name, version)
+				}
+			}
+			if err := lock.Write(lockPath); err != nil {
+				return err
+			}
+			cmd.Printf("wrote %s\n", lockPath)
+			return nil
+		},
+	}
+	InstallManifestFlag(lockCmd)
+	lockCmd.PersistentFlags().StringSlice(
+		CliPlatformFlag, nil,
+		"platform to lock artifacts for, written as os/arch. Can be repeated. By default, the current platform and the platforms already in the lockfile",
+	)
+	InstallMaxVersionsFlag(lockCmd)
+	InstallRefreshFlag(lockCmd)
+	return lockCmd
+}
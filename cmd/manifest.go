@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/manifest"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+const CliManifestFlag = "file"
+
+// InstallManifestFlag adds a flag to the command for the path of the manifest
+func InstallManifestFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringP(
+		CliManifestFlag, "f", manifest.FileName,
+		"path of the manifest. The lockfile is kept next to it",
+	)
+}
+
+// loadManifest loads the manifest that the flag of the command points to,
+// along with its lockfile, which is empty if it does not exist yet
+func loadManifest(cmd *cobra.Command) (*manifest.Manifest, *manifest.Lock, string, error) {
+	p, err := cmd.Flags().GetString(CliManifestFlag)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	m, err := manifest.Load(p)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	lockPath := manifest.LockPath(p)
+	l, err := manifest.ReadLock(lockPath)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return m, l, lockPath, nil
+}
+
+// resolveConstraint resolves the constraint to a version of the binary. If no version matches,
+// the versions are listed again, in case a matching version was released since they were last listed.
+// If none matches still, more versions are listed, up to tool.MaxUpdateVersions, since the
+// constraint may only match versions older than the latest max versions
+func resolveConstraint(
+	ctx context.Context, source tool.VersionSource, b tool.Binary, constraint string, max uint,
+) (string, error) {
+	versions, err := source.Versions(ctx, b, max)
+	if err != nil {
+		return "", err
+	}
+	if v, err := manifest.Resolve(constraint, versions); err == nil {
+		return v, nil
+	}
+	if versions, err = source.Refresh(ctx, b, max); err != nil {
+		return "", err
+	}
+	if max == 0 {
+		max = 1
+	}
+	for {
+		v, err := manifest.Resolve(constraint, versions)
+		if err == nil || uint(len(versions)) < max {
+			return v, err
+		}
+		if max >= tool.MaxUpdateVersions {
+			return "", fmt.Errorf(
+				"%w in the latest %d versions, raise --%s or %s to search more",
+				err, len(versions), CliMaxVersionsInstallFlag, config.MaxVersions,
+			)
+		}
+		if max *= 2; max > tool.MaxUpdateVersions {
+			max = tool.MaxUpdateVersions
+		}
+		if versions, err = source.Versions(ctx, b, max); err != nil {
+			return "", err
+		}
+	}
+}
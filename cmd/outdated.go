@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+const CliOutputFlag = "output"
+
+// outdatedExitCode is the exit code of kpkg outdated when updates are available,
+// so that scripts can tell it from a failure
+const outdatedExitCode = 2
+
+func MakeOutdated(env *Env, tools []tool.Binary) *cobra.Command {
+	var outdatedCmd = &cobra.Command{
+		Use:   "outdated",
+		Short: "List installed binaries with newer versions",
+		Long: `List the installed binaries whose linked version is not the latest, along with the latest patch
+version, the latest minor version and the latest version. Versions are compared as semver. Binaries that
+don't use semver, like mc, only show their latest version. The command exits with 2 if any binary is
+outdated, and with 1 if a binary could not be checked.
+
+At least --max versions are listed, and more until they reach back to the linked version, so that patches
+of an old minor version are found even if they were released after many newer versions. At most 1000
+versions are listed, so updates of a version older than that may be missed`,
+		Example: `
+List outdated binaries:
+kpkg outdated
+
+List outdated binaries as json:
+kpkg outdated -o json
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output, err := cmd.Flags().GetString(CliOutputFlag)
+			if err != nil {
+				return err
+			}
+			if output != "table" && output != "json" {
+				return fmt.Errorf("unsupported output %s, expected table or json", output)
+			}
+			basePath, err := env.Root()
+			if err != nil {
+				return err
+			}
+			source, err := env.versionSource(cmd)
+			if err != nil {
+				return err
+			}
+			max := env.InstallOptions().Max
+			installed, err := tool.ListInstalled(basePath)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := env.Context(cmd)
+			defer cancel()
+
+			updates := make([]tool.Updates, len(installed))
+			errs := make([]error, len(installed))
+			runWorkers(len(installed), func(i int) {
+				updates[i], _, errs[i] = checkUpdates(ctx, source, tools, basePath, installed[i], max)
+			})
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var failed int
+			outdated := []tool.Updates{}
+			for i, err := range errs {
+				if err != nil {
+					failed++
+					cmd.PrintErrf("could not check %s: %s\n", installed[i], err)
+					continue
+				}
+				if updates[i].Outdated() {
+					outdated = append(outdated, updates[i])
+				}
+			}
+
+			if output == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(outdated); err != nil {
+					return err
+				}
+			} else if len(outdated) != 0 {
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "BINARY\tCURRENT\tPATCH\tMINOR\tLATEST")
+				for _, u := range outdated {
+					fmt.Fprintf(
+						w, "%s\t%s\t%s\t%s\t%s\n", u.Binary, u.Current,
+						orDash(u.Patch), orDash(u.Minor), u.Latest,
+					)
+				}
+				if err := w.Flush(); err != nil {
+					return err
+				}
+			} else {
+				cmd.Printf("%d binaries are up to date\n", len(installed)-failed)
+			}
+
+			if failed != 0 {
+				return fmt.Errorf("could not check %d binaries", failed)
+			}
+			if len(outdated) != 0 {
+				cmd.SilenceErrors, cmd.SilenceUsage = true, true
+				return &kpkgerr.ExitErr{Code: outdatedExitCode}
+			}
+			return nil
+		},
+	}
+	outdatedCmd.Flags().StringP(CliOutputFlag, "o", "table", "output format, one of table or json")
+	InstallMaxVersionsFlag(outdatedCmd)
+	InstallRefreshFlag(outdatedCmd)
+	return outdatedCmd
+}
+
+// checkUpdates finds the updates of the linked version of an installed binary, along with the
+// number of versions that were listed to find them. At least the max latest versions are listed,
+// and more if needed to reach back to the linked version, see tool.VersionsSince
+func checkUpdates(
+	ctx context.Context, source tool.VersionSource, tools []tool.Binary, basePath, binary string, max uint,
+) (tool.Updates, uint, error) {
+	name := strings.TrimSuffix(binary, ".exe")
+	b, ok := FindTool(tools, name)
+	if !ok {
+		return tool.Updates{}, 0, fmt.Errorf("unknown binary")
+	}
+	current, err := tool.LinkedVersion(basePath, binary)
+	if err != nil {
+		return tool.Updates{}, 0, err
+	}
+	versions, err := tool.VersionsSince(ctx, source, b, current, max)
+	if err != nil {
+		return tool.Updates{}, 0, err
+	}
+	return tool.FindUpdates(name, current, versions), uint(len(versions)), nil
+}
+
+// orDash returns s, or - if s is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -7,7 +7,7 @@ import (
 
 const CliPurgeFlag = "purge"
 
-func MakeRm(basePath string) *cobra.Command {
+func MakeRm(env *Env) *cobra.Command {
 	var rmCmd = &cobra.Command{
 		Use:   "rm",
 		Short: "Remove or purge a binary",
@@ -30,10 +30,16 @@ func MakeRm(basePath string) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			basePath, err := env.Root()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := env.Context(cmd)
+			defer cancel()
 			if purge {
-				return tool.Purge(basePath, args[0])
+				return tool.Purge(ctx, basePath, args[0])
 			}
-			return tool.RemoveVersions(basePath, args[0], args[1:])
+			return tool.RemoveVersions(ctx, basePath, args[0], args[1:])
 		},
 	}
 
@@ -2,13 +2,95 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/download"
+	"github.com/spachava753/kpkg/pkg/index"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+const (
+	CliLockTimeoutFlag         = "lock-timeout"
+	CliQuietFlag               = "quiet"
+	CliTimeoutFlag             = "timeout"
+	CliGithubApiFlag           = "github-api-url"
+	CliRewriteFlag             = "rewrite"
+	CliRewriteRegexFlag        = "rewrite-regex"
+	CliConnectTimeoutFlag      = "connect-timeout"
+	CliTLSHandshakeTimeoutFlag = "tls-handshake-timeout"
+	CliReadTimeoutFlag         = "read-timeout"
+	CliCABundleFlag            = "ca-bundle"
+	CliProxyFlag               = "proxy"
+	CliInsecureSkipVerifyFlag  = "insecure-skip-verify"
+	CliRootFlag                = "root"
 )
 
-func MakeRoot() *cobra.Command {
+func MakeRoot(env *Env) *cobra.Command {
 	var rootCmd = &cobra.Command{
 		Use:   "kpkg",
 		Short: "kpkg is your goto tool for managing binaries in the Kubernetes ecosystem",
-		Long:  `kpkg is your goto tool for managing binaries in the Kubernetes ecosystem`,
+		Long: `kpkg is your goto tool for managing binaries in the Kubernetes ecosystem.
+Settings are taken from flags, env vars, or the config file, in that order. See kpkg config`,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return env.load(cmd)
+		},
 	}
+
+	rootCmd.PersistentFlags().String(
+		CliRootFlag, "",
+		"dir to keep installed binaries and downloads in. By default, binaries are kept in $XDG_DATA_HOME/kpkg, and downloads in $XDG_CACHE_HOME/kpkg",
+	)
+	rootCmd.PersistentFlags().Duration(
+		CliLockTimeoutFlag, tool.LockTimeout,
+		"how long to wait for other kpkg processes to finish modifying the same binary",
+	)
+	rootCmd.PersistentFlags().BoolP(
+		CliQuietFlag, "q", false,
+		"do not report the progress of downloads and extractions",
+	)
+	rootCmd.PersistentFlags().Duration(
+		CliTimeoutFlag, 0,
+		"give up if the command takes longer than this, for example 5m. By default there is no timeout",
+	)
+	rootCmd.PersistentFlags().String(
+		CliGithubApiFlag, "",
+		"base url of the GitHub API to list versions from, for example https://github.example.com/api/v3/",
+	)
+	rootCmd.PersistentFlags().StringArray(
+		CliRewriteFlag, nil,
+		"rewrite download urls starting with a prefix, written as prefix=>replacement. Can be repeated, the first matching rule is used",
+	)
+	rootCmd.PersistentFlags().StringArray(
+		CliRewriteRegexFlag, nil,
+		"rewrite download urls matching a regular expression, written as regexp=>replacement. Tried after the --rewrite rules",
+	)
+	defaults := download.DefaultTransportOptions()
+	rootCmd.PersistentFlags().Duration(
+		CliConnectTimeoutFlag, defaults.ConnectTimeout,
+		"how long connecting to a server may take",
+	)
+	rootCmd.PersistentFlags().Duration(
+		CliTLSHandshakeTimeoutFlag, defaults.TLSHandshakeTimeout,
+		"how long the TLS handshake with a server may take",
+	)
+	rootCmd.PersistentFlags().Duration(
+		CliReadTimeoutFlag, defaults.ReadTimeout,
+		"how long to wait for a server to respond, or to send more data of a download that stalled",
+	)
+	rootCmd.PersistentFlags().String(
+		CliCABundleFlag, "",
+		"path to a PEM file of CA certificates to trust, on top of the certificates of the system",
+	)
+	rootCmd.PersistentFlags().String(
+		CliProxyFlag, "",
+		"url of the proxy to send requests through, except to the hosts in NO_PROXY. By default, the HTTPS_PROXY and HTTP_PROXY env vars are used",
+	)
+	rootCmd.PersistentFlags().Bool(
+		CliInsecureSkipVerifyFlag, false,
+		"do not verify TLS certificates. This is insecure, only use it to debug TLS issues",
+	)
+	rootCmd.PersistentFlags().Duration(
+		CliIndexTTLFlag, index.DefaultTTL,
+		"how long the local index of versions is used before versions are listed again",
+	)
 	return rootCmd
 }
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/selfupdate"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+const CliRollbackFlag = "rollback"
+
+func MakeSelfUpdate(env *Env, tools []tool.Binary) *cobra.Command {
+	var selfUpdateCmd = &cobra.Command{
+		Use:   "self-update [version]",
+		Short: "Update kpkg itself",
+		Long: `Update the running kpkg executable to a release of kpkg, by default the latest one, wherever the
+executable is. The release must match the checksums.txt published with it, or it is not installed, and the new
+executable must report the version of the release before it replaces the running one at once. Releases up to
+0.4.3 published no checksums, so they can't be installed with self-update. The previous executable is kept
+next to it, so that kpkg self-update --rollback can go back to it. An executable installed with kpkg get kpkg is
+not replaced, the release is installed and linked in its kpkg root instead, and kpkg get kpkg <version> goes back`,
+		Example: `
+Update kpkg to the latest release:
+kpkg self-update
+
+Update kpkg to a specific release:
+kpkg self-update 0.5.0
+
+Go back to the executable from before the last update:
+kpkg self-update --rollback
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rollback, err := cmd.Flags().GetBool(CliRollbackFlag)
+			if err != nil {
+				return err
+			}
+			force, err := cmd.Flags().GetBool(CliForceInstallFlag)
+			if err != nil {
+				return err
+			}
+			exe, err := executablePath()
+			if err != nil {
+				return fmt.Errorf("could not find the kpkg executable: %w", err)
+			}
+			opts := env.InstallOptions()
+			binary := "kpkg"
+			if opts.Windows {
+				binary += ".exe"
+			}
+			installRoot, installedVersion, versioned := versionedInstall(exe, binary)
+
+			if rollback {
+				if len(args) != 0 {
+					return fmt.Errorf("--%s does not take a version", CliRollbackFlag)
+				}
+				if versioned {
+					return fmt.Errorf(
+						"%s was installed with kpkg get as version %s of kpkg in %s, link the previous version again with kpkg get kpkg <version>",
+						exe, installedVersion, installRoot,
+					)
+				}
+				if err := selfupdate.Rollback(exe); err != nil {
+					return err
+				}
+				cmd.Printf(
+					"rolled back %s to the previous executable, run kpkg self-update --%s again to undo\n",
+					exe, CliRollbackFlag,
+				)
+				return nil
+			}
+
+			b, ok := FindTool(tools, "kpkg")
+			if !ok {
+				return fmt.Errorf("kpkg is not a known binary")
+			}
+			source, err := env.versionSource(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := env.Context(cmd)
+			defer cancel()
+			version := "latest"
+			if len(args) != 0 {
+				version = args[0]
+			}
+			if version == "latest" {
+				versions, err := source.Versions(ctx, b, opts.Max)
+				if err != nil {
+					return err
+				}
+				if len(versions) == 0 {
+					return fmt.Errorf("no releases of kpkg found")
+				}
+				version = versions[0]
+			}
+			version = normalizeVersion(version)
+			if version == normalizeVersion(env.KpkgVersion) && !force {
+				cmd.Printf("kpkg is already at version %s\n", version)
+				return nil
+			}
+
+			// the release is installed in a temp root, so that it is verified like any other install,
+			// without linking it or leaving a copy behind in the kpkg root. An executable installed
+			// with kpkg get is a version in a kpkg root, so the release is installed next to it
+			root := installRoot
+			if !versioned {
+				tmpPath, err := ioutil.TempDir("", "kpkg-self-update-")
+				if err != nil {
+					return err
+				}
+				defer os.RemoveAll(tmpPath)
+				if root, err = config.CreateRoot(filepath.Join(tmpPath, "root")); err != nil {
+					return err
+				}
+			}
+			f, err := env.FileFetcher()
+			if err != nil {
+				return err
+			}
+			u, err := env.Unpacker()
+			if err != nil {
+				return err
+			}
+			// the new executable is run before it replaces kpkg, so it must be verified first
+			opts.NoLink, opts.RequireChecksum = true, true
+			opts.Versions = source
+			if opts.Rewriter, err = env.Rewriter(); err != nil {
+				return err
+			}
+			if opts.PublicKey, err = env.CosignKey(b.Name()); err != nil {
+				return err
+			}
+			binaryPath, err := tool.Install(ctx, root, version, b, f, u, opts)
+			if err != nil {
+				return err
+			}
+			if err := checkKpkgVersion(binaryPath, version); err != nil {
+				return err
+			}
+
+			if versioned {
+				// replacing the executable in place would leave its dir and receipt describing
+				// the previous version, so the new version is linked instead
+				opts.NoLink = false
+				if _, err := tool.Install(ctx, root, version, b, f, u, opts); err != nil {
+					return err
+				}
+				cmd.Printf(
+					"installed kpkg %s in %s and linked it, link %s again with kpkg get kpkg %s to go back\n",
+					version, root, installedVersion, installedVersion,
+				)
+				return nil
+			}
+			if err := selfupdate.Replace(exe, binaryPath); err != nil {
+				return err
+			}
+			cmd.Printf(
+				"updated %s from %s to %s, the previous executable is kept for kpkg self-update --%s\n",
+				exe, orDash(env.KpkgVersion), version, CliRollbackFlag,
+			)
+			return nil
+		},
+	}
+	selfUpdateCmd.Flags().Bool(
+		CliRollbackFlag, false, "go back to the executable from before the last update",
+	)
+	selfUpdateCmd.Flags().Bool(
+		CliForceInstallFlag, false, "update even if kpkg is already at the version",
+	)
+	InstallMaxVersionsFlag(selfUpdateCmd)
+	InstallRefreshFlag(selfUpdateCmd)
+	return selfUpdateCmd
+}
+
+// versionedInstall returns the kpkg root and the version that exe is installed as, if it was
+// installed with kpkg get kpkg, in which case it is <root>/kpkg/<version>/kpkg
+func versionedInstall(exe, binary string) (root, version string, ok bool) {
+	versionPath := filepath.Dir(exe)
+	binaryPath := filepath.Dir(versionPath)
+	if filepath.Base(exe) != binary || filepath.Base(binaryPath) != binary {
+		return "", "", false
+	}
+	root, version = filepath.Dir(binaryPath), filepath.Base(versionPath)
+	if installed, err := tool.Installed(root, binary, version); err != nil || !installed {
+		return "", "", false
+	}
+	return root, version, true
+}
+
+// checkKpkgVersion runs kpkg version with the kpkg executable at path, and checks that it reports
+// the version. This makes sure that the executable runs on this platform, and is the expected release
+func checkKpkgVersion(path, version string) error {
+	out, err := exec.Command(path, "version").Output()
+	if err != nil {
+		return fmt.Errorf("could not run the downloaded kpkg %s: %w", version, err)
+	}
+	var v struct {
+		Version string
+	}
+	if err := json.Unmarshal(out, &v); err != nil {
+		return fmt.Errorf("could not read the version of the downloaded kpkg %s: %w", version, err)
+	}
+	if normalizeVersion(v.Version) != version {
+		return fmt.Errorf("the downloaded kpkg reports version %s, instead of %s", v.Version, version)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+// ShellEnvVar is set in the shells started by kpkg shell, to the binaries on their PATH,
+// written as binary@version and separated by spaces
+const ShellEnvVar = "KPKG_SHELL"
+
+func MakeShell(env *Env, tools []tool.Binary) *cobra.Command {
+	var shellCmd = &cobra.Command{
+		Use:   "shell <binary>@<version>...",
+		Short: "Start a shell with versions of binaries on the PATH",
+		Long: `Start $SHELL with versions of binaries on the PATH, without linking them, so the linked versions
+stay as they are. Versions that are not installed are installed first, unless --no-install is set.
+The binaries are linked in a temp dir that is put first on the PATH, and removed once the shell exits.
+The KPKG_SHELL env var of the shell holds the binaries, written as binary@version. Shells started from
+a kpkg shell keep the binaries of the outer shell, unless they replace them`,
+		Example: `
+Start a shell with kubectl 1.19.4 and istioctl 1.8.2:
+kpkg shell kubectl@1.19.4 istioctl@1.8.2
+`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			noInstall, err := cmd.Flags().GetBool(CliNoInstallFlag)
+			if err != nil {
+				return err
+			}
+			binaries := make([]tool.Binary, len(args))
+			versions := make([]string, len(args))
+			for i, arg := range args {
+				if binaries[i], versions[i], err = parseToolVersion(tools, arg); err != nil {
+					return err
+				}
+			}
+
+			binPath, err := ioutil.TempDir("", "kpkg-shell-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(binPath)
+
+			ctx, cancel := env.Context(cmd)
+			defer cancel()
+			specs := make([]string, len(args))
+			for i, b := range binaries {
+				binaryPath, err := env.installUnlinked(ctx, cmd, b, versions[i], "", !noInstall)
+				if err != nil {
+					return err
+				}
+				if err := os.Symlink(binaryPath, filepath.Join(binPath, filepath.Base(binaryPath))); err != nil {
+					return err
+				}
+				specs[i] = b.Name() + "@" + filepath.Base(filepath.Dir(binaryPath))
+			}
+
+			shell := shellPath(env.Os)
+			c := exec.Command(shell)
+			c.Env = setEnv(os.Environ(), "PATH", binPath+string(os.PathListSeparator)+os.Getenv("PATH"))
+			c.Env = setEnv(c.Env, ShellEnvVar, composeShellSpecs(os.Getenv(ShellEnvVar), specs))
+			cmd.PrintErrf("starting %s with %s, exit it to leave\n", shell, strings.Join(specs, " "))
+			err = runChild(c)
+			if _, ok := err.(*kpkgerr.ExitErr); ok {
+				// the shell exits with the code of the last command, which already reported what went wrong
+				cmd.SilenceErrors, cmd.SilenceUsage = true, true
+			}
+			return err
+		},
+	}
+	shellCmd.Flags().Bool(
+		CliNoInstallFlag, false, "fail if a version is not installed, instead of installing it",
+	)
+	InstallRefreshFlag(shellCmd)
+	return shellCmd
+}
+
+// shellPath returns the shell of the user, or the default shell of the os
+func shellPath(goos string) string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	if goos == "windows" {
+		if shell := os.Getenv("COMSPEC"); shell != "" {
+			return shell
+		}
+		return "cmd.exe"
+	}
+	return "/bin/sh"
+}
+
+// setEnv sets the env var key to value in environ, replacing any previous value.
+// Env vars are case insensitive on windows, where PATH is usually written as Path
+func setEnv(environ []string, key, value string) []string {
+	env := make([]string, 0, len(environ)+1)
+	for _, kv := range environ {
+		k := kv
+		if i := strings.Index(kv, "="); i >= 0 {
+			k = kv[:i]
+		}
+		if k != key && (runtime.GOOS != "windows" || !strings.EqualFold(k, key)) {
+			env = append(env, kv)
+		}
+	}
+	return append(env, key+"="+value)
+}
+
+// composeShellSpecs returns the binaries of a shell started from a shell with the outer binaries,
+// where the binaries of the shell replace the outer ones of the same name
+func composeShellSpecs(outer string, specs []string) string {
+	names := map[string]bool{}
+	for _, s := range specs {
+		names[s[:strings.LastIndex(s, "@")]] = true
+	}
+	var composed []string
+	for _, s := range strings.Fields(outer) {
+		if i := strings.LastIndex(s, "@"); i > 0 && names[s[:i]] {
+			continue
+		}
+		composed = append(composed, s)
+	}
+	return strings.Join(append(composed, specs...), " ")
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/manifest"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+const CliShellFlag = "shell"
+
+// githubPathEnvVar is the env var of GitHub Actions that points to the file of dirs to add to the PATH
+const githubPathEnvVar = "GITHUB_PATH"
+
+func MakeShellEnv(env *Env, tools []tool.Binary) *cobra.Command {
+	var envCmd = &cobra.Command{
+		Use:   "env [binary@version...]",
+		Short: "Print the PATH of versions of binaries, for direnv and CI",
+		Long: `Print the statements that put versions of binaries on the PATH, for --shell bash, zsh or fish.
+By default, the shell is taken from $SHELL. The PATH points into the install dirs of the versions, so it does
+not depend on the linked versions. Versions that are not installed are installed first, unless --no-install
+is set, with the output of installing on stderr.
+
+The binaries are given as binary@version, or else taken from the manifest. The versions of the lockfile next
+to the manifest are used if it is up to date, or else the constraints of the manifest are resolved.
+With --shell github, the install dirs are appended to the file of $GITHUB_PATH instead, for GitHub Actions`,
+		Example: `
+Use the binaries of kpkg.yaml in a direnv .envrc:
+eval "$(kpkg env)"
+
+Use kubectl 1.19.4 in fish:
+kpkg env kubectl@1.19.4 --shell fish | source
+
+Add the binaries of kpkg.yaml to the PATH of the next steps of a GitHub Actions job:
+kpkg env --shell github
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			shell, err := cmd.Flags().GetString(CliShellFlag)
+			if err != nil {
+				return err
+			}
+			if shell == "" {
+				shell = filepath.Base(os.Getenv("SHELL"))
+			}
+			switch shell {
+			case "bash", "zsh", "fish", "github":
+			case "", "sh":
+				shell = "bash"
+			default:
+				return fmt.Errorf("unsupported shell %s, expected one of bash, zsh, fish or github", shell)
+			}
+			noInstall, err := cmd.Flags().GetBool(CliNoInstallFlag)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := env.Context(cmd)
+			defer cancel()
+			var requested []requestedTool
+			if len(args) != 0 {
+				for _, arg := range args {
+					b, version, err := parseToolVersion(tools, arg)
+					if err != nil {
+						return err
+					}
+					requested = append(requested, requestedTool{b: b, version: version})
+				}
+			} else if requested, err = manifestTools(ctx, cmd, env, tools); err != nil {
+				return err
+			}
+
+			dirs := make([]string, len(requested))
+			for i, r := range requested {
+				binaryPath, err := env.installUnlinked(ctx, cmd, r.b, r.version, r.sha256, !noInstall)
+				if err != nil {
+					return err
+				}
+				dirs[i] = filepath.Dir(binaryPath)
+			}
+
+			switch shell {
+			case "github":
+				p := os.Getenv(githubPathEnvVar)
+				if p == "" {
+					return fmt.Errorf("%s is not set, --shell github only works in GitHub Actions", githubPathEnvVar)
+				}
+				f, err := os.OpenFile(p, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+				if err != nil {
+					return err
+				}
+				if _, err := f.WriteString(strings.Join(dirs, "\n") + "\n"); err != nil {
+					f.Close()
+					return err
+				}
+				if err := f.Close(); err != nil {
+					return err
+				}
+				cmd.PrintErrf("added %d dirs to %s\n", len(dirs), p)
+			case "fish":
+				quoted := make([]string, len(dirs))
+				for i, d := range dirs {
+					quoted[i] = fishQuote(d)
+				}
+				cmd.Printf("set -gx PATH %s $PATH\n", strings.Join(quoted, " "))
+			default:
+				quoted := make([]string, len(dirs))
+				for i, d := range dirs {
+					quoted[i] = posixQuote(d)
+				}
+				cmd.Printf("export PATH=%s:\"$PATH\"\n", strings.Join(quoted, ":"))
+			}
+			return nil
+		},
+	}
+	envCmd.Flags().String(
+		CliShellFlag, "", "shell to print the statements for, one of bash, zsh, fish or github. Defaults to $SHELL",
+	)
+	envCmd.Flags().Bool(
+		CliNoInstallFlag, false, "fail if a version is not installed, instead of installing it",
+	)
+	InstallManifestFlag(envCmd)
+	InstallMaxVersionsFlag(envCmd)
+	InstallRefreshFlag(envCmd)
+	return envCmd
+}
+
+// requestedTool is a version of a binary that was asked for, along with the digest of its
+// artifact if it was locked
+type requestedTool struct {
+	b               tool.Binary
+	version, sha256 string
+}
+
+// manifestTools returns the versions of the binaries of the manifest. The lockfile pins them if it
+// is up to date, or else the constraints of the manifest are resolved
+func manifestTools(
+	ctx context.Context, cmd *cobra.Command, env *Env, tools []tool.Binary,
+) ([]requestedTool, error) {
+	p, err := cmd.Flags().GetString(CliManifestFlag)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(p); os.IsNotExist(err) {
+		return nil, fmt.Errorf("no binaries given, and no manifest found at %s", p)
+	}
+	m, lock, lockPath, err := loadManifest(cmd)
+	if err != nil {
+		return nil, err
+	}
+	locked := len(lock.Tools) != 0
+	if locked {
+		if err := lock.Check(m); err != nil {
+			cmd.PrintErrf("warning: %s is out of date, resolving the versions of %s: %s\n", lockPath, p, err)
+			locked = false
+		}
+	}
+	platform := manifest.Platform(env.Os, env.Arch)
+
+	var requested []requestedTool
+	for _, name := range m.Names() {
+		b, ok := FindTool(tools, name)
+		if !ok {
+			return nil, fmt.Errorf("unknown binary %s in the manifest", name)
+		}
+		if !locked {
+			source, err := env.versionSource(cmd)
+			if err != nil {
+				return nil, err
+			}
+			version, err := resolveConstraint(ctx, source, b, m.Tools[name], env.InstallOptions().Max)
+			if err != nil {
+				return nil, fmt.Errorf("could not resolve %s %s: %w", name, m.Tools[name], err)
+			}
+			requested = append(requested, requestedTool{b: b, version: version})
+			continue
+		}
+		a, ok := lock.Tools[name].Artifacts[platform]
+		if !ok {
+			return nil, fmt.Errorf(
+				"%s has no artifact of %s for %s, run kpkg lock --platform %s",
+				lockPath, name, platform, platform,
+			)
+		}
+		requested = append(requested, requestedTool{b: b, version: lock.Tools[name].Version, sha256: a.Sha256})
+	}
+	return requested, nil
+}
+
+// posixQuote quotes s for sh, bash and zsh
+func posixQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s for fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+func MakeShims(env *Env) *cobra.Command {
+	var shimsCmd = &cobra.Command{
+		Use:   "shims",
+		Short: "Manage the shims that run the pinned versions of binaries",
+		Long: `Manage the shims that run the pinned versions of binaries. Once shims are enabled, the bin dir
+of the kpkg root holds shims instead of links to the linked versions. A shim runs the version of its
+binary pinned for the current dir by the nearest .kpkg-version file, see kpkg local. Where no version
+is pinned, it runs the linked version`,
+		Example: `
+Enable shims:
+kpkg shims enable
+
+Link the bin dir to the linked versions again:
+kpkg shims disable
+`,
+	}
+
+	enableCmd := &cobra.Command{
+		Use:   "enable",
+		Short: "Replace the links in the bin dir with shims",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			basePath, err := env.Root()
+			if err != nil {
+				return err
+			}
+			shim, err := executablePath()
+			if err != nil {
+				return fmt.Errorf("could not find the kpkg executable to link shims to: %w", err)
+			}
+			ctx, cancel := env.Context(cmd)
+			defer cancel()
+			if err := tool.EnableShims(ctx, basePath, shim); err != nil {
+				return err
+			}
+			cmd.Printf(
+				"shims enabled, binaries in %s now run the versions pinned with kpkg local\n",
+				filepath.Join(basePath, "bin"),
+			)
+			return nil
+		},
+	}
+
+	disableCmd := &cobra.Command{
+		Use:   "disable",
+		Short: "Replace the shims in the bin dir with links to the linked versions",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			basePath, err := env.Root()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := env.Context(cmd)
+			defer cancel()
+			if err := tool.DisableShims(ctx, basePath); err != nil {
+				return err
+			}
+			cmd.Println("shims disabled, binaries in the bin dir are linked to their linked versions")
+			return nil
+		},
+	}
+
+	shimsCmd.AddCommand(enableCmd, disableCmd)
+	return shimsCmd
+}
+
+// RunShim runs the version of the binary that its shim resolves to in the current dir, see
+// tool.ShimTarget. It is run instead of the commands of kpkg when kpkg is run through a shim,
+// so the args are passed on to the binary, and the config is loaded without flags. shim is the
+// path kpkg was run as, and the versions are taken from the root holding the shim, since the
+// root it was enabled in may not be the configured one
+func RunShim(env *Env, shim, binary string, args []string) error {
+	if err := env.loadConfig(nil, os.Stderr); err != nil {
+		return err
+	}
+	if env.Os == "windows" {
+		binary += ".exe"
+	}
+	basePath, ok := shimRoot(shim)
+	if !ok {
+		var err error
+		if basePath, err = env.Root(); err != nil {
+			return err
+		}
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	target, err := tool.ShimTarget(basePath, binary, dir)
+	if err != nil {
+		return fmt.Errorf("kpkg shim: %w", err)
+	}
+	// the binary replaces kpkg, so deferred cleanup would not run
+	if err := env.Close(); err != nil {
+		return err
+	}
+	return execBinary(target, args)
+}
+
+// shimRoot returns the kpkg root of the shim that kpkg was run as. A shim run from the PATH is
+// only given its name, so it is looked up in the PATH like the shell did
+func shimRoot(shim string) (string, bool) {
+	p, err := exec.LookPath(shim)
+	if err != nil {
+		return "", false
+	}
+	if p, err = filepath.Abs(p); err != nil {
+		return "", false
+	}
+	return tool.ShimRoot(p)
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/manifest"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+const CliCheckFlag = "check"
+
+func MakeSync(env *Env, tools []tool.Binary) *cobra.Command {
+	var syncCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "Install the versions of the lockfile",
+		Long: `Install the versions of the binaries in kpkg.lock, and link them. Artifacts are verified against
+the digests in the lockfile, so they are not looked up in the list of versions, however old they are. A version
+that is installed from a different artifact is installed again.
+Binaries that are not in the manifest are left alone. With --check, nothing is installed, and the command
+fails if any binary drifted from the lockfile`,
+		Example: `
+Install the versions of kpkg.lock:
+kpkg sync
+
+Fail if the installed binaries drifted from kpkg.lock, for example in CI:
+kpkg sync --check
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			check, err := cmd.Flags().GetBool(CliCheckFlag)
+			if err != nil {
+				return err
+			}
+			m, lock, lockPath, err := loadManifest(cmd)
+			if err != nil {
+				return err
+			}
+			if err := lock.Check(m); err != nil {
+				return fmt.Errorf("%s is out of date, run kpkg lock: %w", lockPath, err)
+			}
+			basePath, err := env.Root()
+			if err != nil {
+				return err
+			}
+			platform := manifest.Platform(env.Os, env.Arch)
+			opts := env.InstallOptions()
+
+			type drifted struct {
+				name, reason string
+				reinstall    bool
+			}
+			var drifts []drifted
+			for _, name := range m.Names() {
+				locked := lock.Tools[name]
+				a, ok := locked.Artifacts[platform]
+				if !ok {
+					return fmt.Errorf(
+						"%s has no artifact of %s for %s, run kpkg lock --platform %s",
+						lockPath, name, platform, platform,
+					)
+				}
+				binary := name
+				if opts.Windows {
+					binary += ".exe"
+				}
+				reason, reinstall, err := drift(basePath, binary, locked.Version, a.Sha256)
+				if err != nil {
+					return err
+				}
+				if reason != "" {
+					drifts = append(drifts, drifted{name: name, reason: reason, reinstall: reinstall})
+				}
+			}
+
+			if check {
+				for _, d := range drifts {
+					cmd.Printf("%s %s: %s\n", d.name, lock.Tools[d.name].Version, d.reason)
+				}
+				if len(drifts) != 0 {
+					return fmt.Errorf("%d binaries drifted from %s", len(drifts), lockPath)
+				}
+				cmd.Printf("%d binaries match %s\n", len(m.Tools), lockPath)
+				return nil
+			}
+
+			if len(drifts) == 0 {
+				cmd.Printf("%d binaries already match %s\n", len(m.Tools), lockPath)
+				return nil
+			}
+			f, err := env.FileFetcher()
+			if err != nil {
+				return err
+			}
+			u, err := env.Unpacker()
+			if err != nil {
+				return err
+			}
+			if opts.Rewriter, err = env.Rewriter(); err != nil {
+				return err
+			}
+			if opts.Versions, err = env.versionSource(cmd); err != nil {
+				return err
+			}
+			ctx, cancel := env.Context(cmd)
+			defer cancel()
+			for _, d := range drifts {
+				b, ok := FindTool(tools, d.name)
+				if !ok {
+					return fmt.Errorf("unknown binary %s in the manifest", d.name)
+				}
+				locked := lock.Tools[d.name]
+				cmd.Printf("syncing %s %s: %s\n", d.name, locked.Version, d.reason)
+				opts.Force = d.reinstall
+				opts.Sha256 = locked.Artifacts[platform].Sha256
+				if opts.PublicKey, err = env.CosignKey(d.name); err != nil {
+					return err
+				}
+				if _, err := tool.Install(ctx, basePath, locked.Version, b, f, u, opts); err != nil {
+					return fmt.Errorf("could not sync %s: %w", d.name, err)
+				}
+			}
+			cmd.Printf("synced %d binaries with %s\n", len(drifts), lockPath)
+			return nil
+		},
+	}
+	InstallManifestFlag(syncCmd)
+	syncCmd.PersistentFlags().Bool(
+		CliCheckFlag, false,
+		"only check that the installed binaries match the lockfile, and fail if they don't",
+	)
+	InstallRefreshFlag(syncCmd)
+	return syncCmd
+}
+
+// drift describes how the install of a binary differs from its locked version and artifact,
+// or returns an empty reason if it does not. A version installed from a different artifact,
+// or without a receipt to tell, must be installed again
+func drift(basePath, binary, version, sha256 string) (reason string, reinstall bool, err error) {
+	installed, err := tool.Installed(basePath, binary, version)
+	if err != nil {
+		return "", false, err
+	}
+	if !installed {
+		return "not installed", false, nil
+	}
+	receipt, err := tool.ReadReceipt(basePath, binary, version)
+	if err != nil {
+		return "", false, err
+	}
+	if receipt == nil {
+		return "installed without a receipt, so its artifact can't be verified", true, nil
+	}
+	if !strings.EqualFold(receipt.ArtifactSha256, sha256) {
+		return fmt.Sprintf(
+			"installed from an artifact with sha256 %s, instead of %s", receipt.ArtifactSha256, sha256,
+		), true, nil
+	}
+	linked, err := tool.LinkedVersion(basePath, binary)
+	if err != nil {
+		return fmt.Sprintf("its link is broken: %s", err), false, nil
+	}
+	switch linked {
+	case version:
+		return "", false, nil
+	case "":
+		return "not linked", false, nil
+	default:
+		return fmt.Sprintf("%s is linked instead", linked), false, nil
+	}
+}
@@ -125,3 +125,13 @@ func GetTools(os, arch string) []tool.Binary {
 		consul.MakeBinary(os, arch),
 	}
 }
+
+// FindTool returns the tool with the name, if there is one
+func FindTool(tools []tool.Binary, name string) (tool.Binary, bool) {
+	for _, t := range tools {
+		if t.Name() == name {
+			return t, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/index"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+const CliIndexTTLFlag = "index-ttl"
+const CliRefreshFlag = "refresh"
+
+// updateWorkers is the number of binaries whose versions are listed at the same time
+const updateWorkers = 8
+
+func MakeUpdate(env *Env, tools []tool.Binary) *cobra.Command {
+	var updateCmd = &cobra.Command{
+		Use:   "update",
+		Short: "Update the local index of versions",
+		Long: `Update the local index of versions, by listing the versions of all binaries again.
+Installing or listing the versions of a binary uses the index, and only lists the versions again once
+they are older than --index-ttl. Versions that did not change since the last update are revalidated,
+instead of being downloaded again`,
+		Example: `
+Update the versions of all binaries:
+kpkg update
+`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			idx, err := env.Index()
+			if err != nil {
+				return err
+			}
+			max := env.Config().GetUint(config.MaxVersions)
+			ctx, cancel := env.Context(cmd)
+			defer cancel()
+
+			errs := make([]error, len(tools))
+			runWorkers(len(tools), func(i int) {
+				_, errs[i] = idx.Refresh(ctx, tools[i], max)
+			})
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			var failed int
+			for i, err := range errs {
+				if err != nil {
+					failed++
+					cmd.PrintErrf("could not update %s: %s\n", tools[i].Name(), err)
+				}
+			}
+			cmd.Printf("updated %d binaries in %s\n", len(tools)-failed, idx.Dir())
+			if failed != 0 {
+				return fmt.Errorf("could not update %d binaries", failed)
+			}
+			return nil
+		},
+	}
+	InstallMaxVersionsFlag(updateCmd)
+	return updateCmd
+}
+
+// runWorkers calls job with the index of each of n jobs, running updateWorkers jobs at a time.
+// Jobs record their results at their index
+func runWorkers(n int, job func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < updateWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				job(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// InstallRefreshFlag adds a flag to the command to list versions again, instead of using the index
+func InstallRefreshFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool(
+		CliRefreshFlag, false,
+		"list versions again, instead of using the local index of versions",
+	)
+}
+
+// versionSource returns the index, using its entries for as long as the config and the
+// flags of the command allow
+func (e *Env) versionSource(cmd *cobra.Command) (*index.Index, error) {
+	idx, err := e.Index()
+	if err != nil {
+		return nil, err
+	}
+	ttl := e.config.GetDuration(config.IndexTTL)
+	refresh, err := cmd.Flags().GetBool(CliRefreshFlag)
+	if err != nil {
+		return nil, err
+	}
+	if refresh {
+		ttl = 0
+	}
+	return idx.WithTTL(ttl), nil
+}
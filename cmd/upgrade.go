@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+const (
+	CliAllFlag      = "all"
+	CliStrategyFlag = "strategy"
+)
+
+// upgrade strategies, which bound the versions that binaries are upgraded to
+const (
+	strategyPatch = "patch"
+	strategyMinor = "minor"
+	strategyMajor = "major"
+)
+
+func MakeUpgrade(env *Env, tools []tool.Binary) *cobra.Command {
+	var upgradeCmd = &cobra.Command{
+		Use:   "upgrade [binary...]",
+		Short: "Upgrade installed binaries to newer versions",
+		Long: `Install the newest version of installed binaries that the strategy allows, and link it. With
+--strategy patch, binaries are upgraded to the latest version of their minor version, and with minor, to the latest
+version of their major version. With major, binaries are upgraded to their latest version, which is the only
+upgrade of binaries that don't use semver, like mc. Versions are listed back to the linked version, like kpkg
+outdated lists them, so that the patches of an old minor version are found.
+
+Binaries whose version is pinned with the tools.<name>.version setting, or that are held with the tools.<name>.hold
+setting, are left as they are. An upgrade that fails leaves the previous version linked. Once done, a summary of
+the upgrades is printed`,
+		Example: `
+Upgrade kubectl and helm to their latest versions:
+kpkg upgrade kubectl helm
+
+Take the patches of all installed binaries, without crossing a minor version:
+kpkg upgrade --all --strategy patch
+
+Hold helm at its linked version:
+kpkg config set tools.helm.hold true
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			all, err := cmd.Flags().GetBool(CliAllFlag)
+			if err != nil {
+				return err
+			}
+			strategy, err := cmd.Flags().GetString(CliStrategyFlag)
+			if err != nil {
+				return err
+			}
+			switch strategy {
+			case strategyPatch, strategyMinor, strategyMajor:
+			default:
+				return fmt.Errorf(
+					"unsupported strategy %s, expected one of %s, %s or %s",
+					strategy, strategyPatch, strategyMinor, strategyMajor,
+				)
+			}
+			if all == (len(args) != 0) {
+				return fmt.Errorf("expected either binaries to upgrade, or --%s", CliAllFlag)
+			}
+
+			basePath, err := env.Root()
+			if err != nil {
+				return err
+			}
+			opts := env.InstallOptions()
+			binaries := args
+			if all {
+				if binaries, err = tool.ListInstalled(basePath); err != nil {
+					return err
+				}
+			} else {
+				for i, name := range binaries {
+					if _, ok := FindTool(tools, name); !ok {
+						return fmt.Errorf("unknown binary %s", name)
+					}
+					if opts.Windows {
+						binaries[i] += ".exe"
+					}
+				}
+			}
+
+			// held and pinned binaries are skipped, the others are checked for updates
+			type upgrade struct {
+				binary, from, to, status string
+				failed                   bool
+				// listed is the number of versions listed to find the upgrade
+				listed uint
+			}
+			upgrades := make([]upgrade, len(binaries))
+			cfg := env.Config()
+			for i, binary := range binaries {
+				name := strings.TrimSuffix(binary, ".exe")
+				upgrades[i].binary = name
+				if cfg.GetBool(config.ToolKey(name, config.ToolHold)) {
+					upgrades[i].status = "held"
+				} else if v := cfg.GetString(config.ToolKey(name, config.ToolVersion)); v != "latest" {
+					upgrades[i].status = fmt.Sprintf("pinned to %s", v)
+				}
+			}
+			source, err := env.versionSource(cmd)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := env.Context(cmd)
+			defer cancel()
+			runWorkers(len(binaries), func(i int) {
+				up := &upgrades[i]
+				if up.status != "" {
+					up.from, _ = tool.LinkedVersion(basePath, binaries[i])
+					return
+				}
+				updates, listed, err := checkUpdates(ctx, source, tools, basePath, binaries[i], opts.Max)
+				switch {
+				case err != nil:
+					up.status, up.failed = fmt.Sprintf("failed: %s", err), true
+				case updates.Current == "":
+					up.status = fmt.Sprintf("failed: not installed, install it with kpkg get %s", up.binary)
+					up.failed = true
+				default:
+					up.from, up.to = updates.Current, upgradeVersion(updates, strategy)
+					up.listed = listed
+					if up.to == "" {
+						up.status = "up to date"
+					}
+				}
+			})
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			f, err := env.FileFetcher()
+			if err != nil {
+				return err
+			}
+			u, err := env.Unpacker()
+			if err != nil {
+				return err
+			}
+			if opts.Rewriter, err = env.Rewriter(); err != nil {
+				return err
+			}
+			opts.Versions = source
+
+			// upgrades are installed one at a time, so that their output is not interleaved
+			var failed int
+			for i := range upgrades {
+				up := &upgrades[i]
+				if up.failed {
+					failed++
+				}
+				if up.status != "" || ctx.Err() != nil {
+					continue
+				}
+				b, _ := FindTool(tools, up.binary)
+				if opts.PublicKey, err = env.CosignKey(up.binary); err != nil {
+					return err
+				}
+				cmd.Printf("upgrading %s from %s to %s\n", up.binary, up.from, up.to)
+				// the version may be older than the max latest versions, like a patch of an old minor
+				// version, so install searches through as many versions as were listed to find it
+				installOpts := opts
+				if up.listed > installOpts.Max {
+					installOpts.Max = up.listed
+				}
+				if _, err := tool.Install(ctx, basePath, up.to, b, f, u, installOpts); err != nil {
+					failed++
+					up.status = fmt.Sprintf("failed, %s is still linked: %s", up.from, err)
+					continue
+				}
+				up.status = "upgraded"
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "BINARY\tFROM\tTO\tSTATUS")
+			for _, up := range upgrades {
+				if up.status == "" {
+					// the upgrade was not attempted, since the command was cancelled
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", up.binary, orDash(up.from), orDash(up.to), up.status)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if failed != 0 {
+				return fmt.Errorf("could not upgrade %d binaries", failed)
+			}
+			return nil
+		},
+	}
+	upgradeCmd.Flags().Bool(CliAllFlag, false, "upgrade all installed binaries")
+	upgradeCmd.Flags().String(
+		CliStrategyFlag, strategyMajor,
+		"newest version to upgrade to, one of patch, minor or major. patch keeps the minor version, and minor keeps the major version",
+	)
+	InstallMaxVersionsFlag(upgradeCmd)
+	InstallRefreshFlag(upgradeCmd)
+	return upgradeCmd
+}
+
+// upgradeVersion returns the version that the strategy upgrades to, or an empty string if there is none
+func upgradeVersion(u tool.Updates, strategy string) string {
+	switch strategy {
+	case strategyPatch:
+		return u.Patch
+	case strategyMinor:
+		return u.Minor
+	default:
+		return u.Latest
+	}
+}
@@ -2,26 +2,63 @@ package cmd
 
 import (
 	"encoding/json"
+	"fmt"
+
 	"github.com/spf13/cobra"
+
+	"github.com/spachava753/kpkg/pkg/tool"
 )
 
-func MakeVersion(version, commit, goVersion string) *cobra.Command {
+const CliCheckUpdateFlag = "check"
+
+func MakeVersion(env *Env, tools []tool.Binary, version, commit, goVersion string) *cobra.Command {
 	var versionCmd = &cobra.Command{
 		Use:   "version",
 		Short: "Version of this release",
-		Long:  `Version of this release`,
+		Long: `Version of this release. With --check, the latest release is listed too, along with whether
+it is newer than this release`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			return cobra.NoArgs(cmd, args)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			enc := json.NewEncoder(cmd.OutOrStdout())
-			return enc.Encode(map[string]string{
+			check, err := cmd.Flags().GetBool(CliCheckUpdateFlag)
+			if err != nil {
+				return err
+			}
+			info := map[string]interface{}{
 				"Version":   version,
 				"GitCommit": commit,
 				"GoVersion": goVersion,
-			})
+			}
+			if check {
+				b, ok := FindTool(tools, "kpkg")
+				if !ok {
+					return fmt.Errorf("kpkg is not a known binary")
+				}
+				source, err := env.versionSource(cmd)
+				if err != nil {
+					return err
+				}
+				ctx, cancel := env.Context(cmd)
+				defer cancel()
+				versions, err := source.Versions(ctx, b, env.InstallOptions().Max)
+				if err != nil {
+					return err
+				}
+				updates := tool.FindUpdates(b.Name(), normalizeVersion(version), versions)
+				if len(versions) != 0 {
+					info["LatestVersion"] = versions[0]
+				}
+				info["UpdateAvailable"] = updates.Outdated()
+				if updates.Outdated() {
+					cmd.PrintErrf("kpkg %s is available, update with kpkg self-update\n", updates.Latest)
+				}
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			return enc.Encode(info)
 		},
 	}
-
+	versionCmd.Flags().Bool(CliCheckUpdateFlag, false, "check if there is a newer release")
+	InstallRefreshFlag(versionCmd)
 	return versionCmd
 }
@@ -9,5 +9,9 @@ require (
 	github.com/magefile/mage v1.11.0
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/spf13/cobra v1.2.1
+	github.com/spf13/pflag v1.0.5
 	github.com/thoas/go-funk v0.7.0
+	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
+	golang.org/x/sys v0.0.0-20210510120138-977fb7262007
+	gopkg.in/yaml.v2 v2.4.0
 )
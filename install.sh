@@ -5,13 +5,23 @@
 set -eu
 
 KPKG_VERSION=${KPKG_VERSION:-0.4.3}
-KPKG_ROOT=${KPKG_ROOT:-"${HOME}/.kpkg"}
+# kpkg installs binaries in $XDG_DATA_HOME/kpkg by default. A custom root must be exported
+# for kpkg as well, so that it installs binaries in the same root
+KPKG_CUSTOM_ROOT=${KPKG_ROOT:-}
+KPKG_ROOT=${KPKG_ROOT:-"${XDG_DATA_HOME:-${HOME}/.local/share}/kpkg"}
+# an existing ~/.kpkg is migrated by kpkg itself, and keeps working once it is
+if [ -z "${KPKG_CUSTOM_ROOT}" ] && [ -e "${HOME}/.kpkg" ]; then
+  KPKG_ROOT="${HOME}/.kpkg"
+fi
 
 happyexit() {
   echo ""
   echo "Add kpkg CLI and kpkg installed binaries to your path with:"
   echo ""
   echo "  export PATH=\$PATH:${KPKG_ROOT}/bin:${KPKG_ROOT}/original"
+  if [ -n "${KPKG_CUSTOM_ROOT}" ]; then
+    echo "  export KPKG_ROOT=${KPKG_ROOT}"
+  fi
   echo ""
   echo "Now run:"
   echo ""
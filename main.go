@@ -1,13 +1,17 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/mitchellh/go-homedir"
 	"github.com/spachava753/kpkg/cmd"
-	"github.com/spachava753/kpkg/pkg/config"
-	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"strings"
+	"syscall"
 )
 
 var cliOs = runtime.GOOS
@@ -21,51 +25,91 @@ var (
 
 func main() {
 	if err := run(); err != nil {
+		var exitErr *kpkgerr.ExitErr
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.Code)
+		}
 		_, _ = fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
 func run() error {
-	// fetch the users home dir
-	hDir, err := homedir.Dir()
+	// commands share an env, which is set up from the config and flags once they are parsed
+	env, err := cmd.MakeEnv(version, cliOs, cliArch)
 	if err != nil {
 		return err
 	}
+	// artifacts are downloaded into a temp dir, which is removed once kpkg exits,
+	// including when it is interrupted
+	defer env.Close()
 
-	// set up kpkg's necessary folders if not setup already (.kpkg, .kpkg/bin)
-	root, err := config.CreatePath(hDir)
-	if err != nil {
-		return err
+	tools := cmd.GetTools(cliOs, cliArch)
+
+	// kpkg is run through a shim if it is run under the name of a binary
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	if _, ok := cmd.FindTool(tools, name); ok && name != "kpkg" {
+		return cmd.RunShim(env, os.Args[0], name, os.Args[1:])
 	}
 
 	// create instances of top level commands
-	rootCmd := cmd.MakeRoot()
+	rootCmd := cmd.MakeRoot(env)
 	getCmd := cmd.MakeGet()
-	listCmd := cmd.MakeList(root)
-	rmCmd := cmd.MakeRm(root)
-	versionCmd := cmd.MakeVersion(version, commit, goVersion)
-
-	fileFetcher, err := download.InitFileFetcher()
-	if err != nil {
-		return err
-	}
+	listCmd := cmd.MakeList(env)
+	rmCmd := cmd.MakeRm(env)
+	cacheCmd := cmd.MakeCache(env)
+	configCmd := cmd.MakeConfig(env)
 
-	tools := cmd.GetTools(cliOs, cliArch)
+	cmd.MakeGetBinarySubCmds(env, getCmd, tools)
 
-	cmd.MakeGetBinarySubCmds(root, getCmd, tools, fileFetcher, cliOs == "windows")
+	cmd.MakeListBinarySubCmds(env, listCmd, tools)
 
-	cmd.MakeListBinarySubCmds(listCmd, tools, root)
+	updateCmd := cmd.MakeUpdate(env, tools)
+	lockCmd := cmd.MakeLock(env, tools)
+	syncCmd := cmd.MakeSync(env, tools)
+	localCmd := cmd.MakeLocal(env, tools)
+	shimsCmd := cmd.MakeShims(env)
+	execCmd := cmd.MakeExec(env, tools)
+	shellCmd := cmd.MakeShell(env, tools)
+	envCmd := cmd.MakeShellEnv(env, tools)
+	outdatedCmd := cmd.MakeOutdated(env, tools)
+	upgradeCmd := cmd.MakeUpgrade(env, tools)
+	versionCmd := cmd.MakeVersion(env, tools, version, commit, goVersion)
+	selfUpdateCmd := cmd.MakeSelfUpdate(env, tools)
 
-	rootCmd.AddCommand(getCmd, listCmd, rmCmd, versionCmd)
+	rootCmd.AddCommand(
+		getCmd, listCmd, rmCmd, versionCmd, cacheCmd, updateCmd, configCmd, lockCmd, syncCmd,
+		localCmd, shimsCmd, execCmd, shellCmd, envCmd, outdatedCmd, upgradeCmd, selfUpdateCmd,
+	)
 
 	// set outputs
 	rootCmd.SetOut(os.Stdout)
 	rootCmd.SetErr(os.Stderr)
 
-	if err := rootCmd.Execute(); err != nil {
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// interruptContext returns a context that is cancelled when kpkg is interrupted, so that
+// commands can stop and clean up after themselves. A second interrupt kills kpkg right away
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case <-signals:
+			_, _ = fmt.Fprintln(os.Stderr, "interrupted, cleaning up...")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
@@ -0,0 +1,404 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v2"
+
+	"github.com/spachava753/kpkg/pkg/download"
+)
+
+// Source is where the value of a setting was set
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// Value is the value of a setting, along with where it was set.
+// Values of list settings are held in List instead of Value
+type Value struct {
+	Key    string
+	Value  string
+	List   []string
+	Source Source
+}
+
+// String formats the value for display, with the items of lists separated by commas
+func (v Value) String() string {
+	if v.List != nil {
+		return strings.Join(v.List, ", ")
+	}
+	return v.Value
+}
+
+// Config holds the settings of kpkg. A setting is taken from the first of these that sets it:
+// a flag, an env var, the config file, or its default
+type Config struct {
+	path string
+	// file holds the settings of the config file, nested like in the file
+	file   map[string]interface{}
+	flags  map[string]Value
+	getenv func(string) string
+}
+
+// DefaultPath returns the path of the config file. It is $KPKG_CONFIG if set, or else
+// config.yaml in the kpkg dir of $XDG_CONFIG_HOME, which defaults to ~/.config
+func DefaultPath() (string, error) {
+	if p := os.Getenv("KPKG_CONFIG"); p != "" {
+		return p, nil
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return "", err
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "kpkg", "config.yaml"), nil
+}
+
+// Load loads the config file at path, which may not exist, along with the env vars of the settings.
+// It fails if the file or the env vars hold unknown settings or invalid values
+func Load(path string) (*Config, error) {
+	return load(path, os.Getenv)
+}
+
+func load(path string, getenv func(string) string) (*Config, error) {
+	c := &Config{
+		path:   path,
+		file:   map[string]interface{}{},
+		flags:  map[string]Value{},
+		getenv: getenv,
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		var raw map[string]interface{}
+		if err := yaml.Unmarshal(b, &raw); err != nil {
+			return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+		}
+		c.file = normalize(raw)
+	}
+
+	values := map[string]Value{}
+	flatten("", c.file, values)
+	for key, v := range values {
+		if err := validate(key, v); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+	for _, s := range settings {
+		if v, ok := c.env(s); ok {
+			if err := validate(s.Key, v); err != nil {
+				return nil, fmt.Errorf("invalid env var: %w", err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// Path returns the path of the config file
+func (c *Config) Path() string {
+	return c.path
+}
+
+// SetFlag sets the value of a setting from a flag, which takes precedence over anything else
+func (c *Config) SetFlag(key string, values ...string) error {
+	s, err := Describe(key)
+	if err != nil {
+		return err
+	}
+	v := Value{Key: key, Source: SourceFlag}
+	if s.Kind.IsList() {
+		v.List = append([]string{}, values...)
+	} else if len(values) == 1 {
+		v.Value = values[0]
+	} else {
+		return fmt.Errorf("setting %s takes a single value", key)
+	}
+	if err := validate(key, v); err != nil {
+		return err
+	}
+	c.flags[key] = v
+	return nil
+}
+
+// Get returns the value of the setting, and where it was set
+func (c *Config) Get(key string) (Value, error) {
+	s, err := Describe(key)
+	if err != nil {
+		return Value{}, err
+	}
+	if v, ok := c.flags[key]; ok {
+		return v, nil
+	}
+	if v, ok := c.env(s); ok {
+		return v, nil
+	}
+	if raw, ok := lookup(c.file, strings.Split(key, ".")); ok {
+		if v, ok := leaf(key, raw); ok {
+			return v, nil
+		}
+	}
+	v := Value{Key: key, Value: s.Default, Source: SourceDefault}
+	if s.Kind.IsList() {
+		v = Value{Key: key, List: []string{}, Source: SourceDefault}
+	}
+	return v, nil
+}
+
+// List returns the values of all settings, including the per tool settings in the config file,
+// sorted by key
+func (c *Config) List() ([]Value, error) {
+	keys := map[string]bool{}
+	for _, s := range settings {
+		keys[s.Key] = true
+	}
+	values := map[string]Value{}
+	flatten("", c.file, values)
+	for key := range values {
+		keys[key] = true
+	}
+
+	list := make([]Value, 0, len(keys))
+	for key := range keys {
+		v, err := c.Get(key)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list, nil
+}
+
+// Set validates the value of the setting, and writes it to the config file
+func (c *Config) Set(key string, values ...string) error {
+	s, err := Describe(key)
+	if err != nil {
+		return err
+	}
+	v := Value{Key: key, Source: SourceFile}
+	var raw interface{}
+	switch {
+	case s.Kind.IsList():
+		v.List = values
+		list := make([]interface{}, 0, len(values))
+		for _, item := range values {
+			list = append(list, item)
+		}
+		raw = list
+	case len(values) != 1:
+		return fmt.Errorf("setting %s takes a single value", key)
+	default:
+		v.Value = values[0]
+		raw = values[0]
+	}
+	if err := validate(key, v); err != nil {
+		return err
+	}
+	// keep numbers and booleans unquoted in the config file
+	switch s.Kind {
+	case Uint:
+		raw, _ = strconv.ParseUint(v.Value, 10, 0)
+	case Bool:
+		raw, _ = strconv.ParseBool(v.Value)
+	}
+
+	set(c.file, strings.Split(key, "."), raw)
+	b, err := yaml.Marshal(c.file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), os.ModePerm); err != nil {
+		return err
+	}
+	// the config file may hold a token, so only the user may read it
+	return ioutil.WriteFile(c.path, b, 0600)
+}
+
+// GetString returns the value of a string setting, with a leading ~ of paths expanded
+func (c *Config) GetString(key string) string {
+	v, _ := c.Get(key)
+	if s, err := Describe(key); err == nil && s.Kind == Path && v.Value != "" {
+		if p, err := homedir.Expand(v.Value); err == nil {
+			return p
+		}
+	}
+	return v.Value
+}
+
+// GetStringSlice returns the value of a list setting
+func (c *Config) GetStringSlice(key string) []string {
+	v, _ := c.Get(key)
+	return v.List
+}
+
+// GetUint returns the value of a Uint setting. Values are validated when they are set,
+// so the getters of typed settings don't fail
+func (c *Config) GetUint(key string) uint {
+	v, _ := c.Get(key)
+	u, _ := strconv.ParseUint(v.Value, 10, 0)
+	return uint(u)
+}
+
+// GetBool returns the value of a Bool setting
+func (c *Config) GetBool(key string) bool {
+	v, _ := c.Get(key)
+	b, _ := strconv.ParseBool(v.Value)
+	return b
+}
+
+// GetDuration returns the value of a Duration setting
+func (c *Config) GetDuration(key string) time.Duration {
+	v, _ := c.Get(key)
+	d, _ := time.ParseDuration(v.Value)
+	return d
+}
+
+// GetSize returns the value of a Size setting, in bytes
+func (c *Config) GetSize(key string) int64 {
+	v, _ := c.Get(key)
+	size, _ := download.ParseSize(v.Value)
+	return size
+}
+
+// env returns the value of the setting from the first of its env vars that is set
+func (c *Config) env(s Setting) (Value, bool) {
+	for _, env := range s.Env {
+		e := c.getenv(env)
+		if e == "" {
+			continue
+		}
+		if s.Kind.IsList() {
+			return Value{Key: s.Key, List: strings.Fields(e), Source: SourceEnv}, true
+		}
+		return Value{Key: s.Key, Value: e, Source: SourceEnv}, true
+	}
+	return Value{}, false
+}
+
+// validate checks that the setting exists, and that v is a valid value of it
+func validate(key string, v Value) error {
+	s, err := Describe(key)
+	if err != nil {
+		return err
+	}
+	if s.Kind.IsList() {
+		for _, item := range v.List {
+			if err := s.Kind.validate(item); err != nil {
+				return fmt.Errorf("invalid value %q for %s: %w", item, key, err)
+			}
+		}
+		return nil
+	}
+	if v.List != nil {
+		return fmt.Errorf("setting %s takes a single value, not a list", key)
+	}
+	if err := s.Kind.validate(v.Value); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", v.Value, key, err)
+	}
+	return nil
+}
+
+// normalize converts the maps decoded from yaml into maps keyed by strings
+func normalize(raw map[string]interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		m[k] = normalizeValue(v)
+	}
+	return m
+}
+
+func normalizeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[fmt.Sprint(k)] = normalizeValue(item)
+		}
+		return m
+	case map[string]interface{}:
+		return normalize(v)
+	}
+	return v
+}
+
+// flatten collects the leaves of the nested settings, keyed by their dotted key
+func flatten(prefix string, m map[string]interface{}, values map[string]Value) {
+	for k, raw := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := raw.(map[string]interface{}); ok {
+			flatten(key, nested, values)
+			continue
+		}
+		if v, ok := leaf(key, raw); ok {
+			values[key] = v
+		}
+	}
+}
+
+// leaf converts a value from the config file. A scalar given for a list setting is a list of one
+func leaf(key string, raw interface{}) (Value, bool) {
+	v := Value{Key: key, Source: SourceFile}
+	switch raw := raw.(type) {
+	case nil:
+		return Value{}, false
+	case map[string]interface{}:
+		return Value{}, false
+	case []interface{}:
+		v.List = make([]string, 0, len(raw))
+		for _, item := range raw {
+			v.List = append(v.List, fmt.Sprint(item))
+		}
+	default:
+		v.Value = fmt.Sprint(raw)
+		if s, err := Describe(key); err == nil && s.Kind.IsList() {
+			v.List, v.Value = []string{v.Value}, ""
+		}
+	}
+	return v, true
+}
+
+func lookup(m map[string]interface{}, parts []string) (interface{}, bool) {
+	v, ok := m[parts[0]]
+	if !ok || len(parts) == 1 {
+		return v, ok
+	}
+	nested, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookup(nested, parts[1:])
+}
+
+func set(m map[string]interface{}, parts []string, v interface{}) {
+	if len(parts) == 1 {
+		m[parts[0]] = v
+		return
+	}
+	nested, ok := m[parts[0]].(map[string]interface{})
+	if !ok {
+		nested = map[string]interface{}{}
+		m[parts[0]] = nested
+	}
+	set(nested, parts[1:], v)
+}
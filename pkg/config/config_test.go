@@ -0,0 +1,231 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(p, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func envOf(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
+
+func TestConfig_Get(t *testing.T) {
+	file := `
+retries: 5
+maxVersions: 10
+timeouts:
+  read: 1m
+mirrors:
+  rewrite: https://github.com/=>https://mirror.example.com/github/
+tools:
+  kubectl:
+    version: 1.20.0
+`
+	tests := []struct {
+		name  string
+		key   string
+		env   map[string]string
+		flags map[string][]string
+		want  Value
+	}{
+		{
+			name: "default",
+			key:  Quiet,
+			want: Value{Key: Quiet, Value: "false", Source: SourceDefault},
+		},
+		{
+			name: "file",
+			key:  Retries,
+			want: Value{Key: Retries, Value: "5", Source: SourceFile},
+		},
+		{
+			name: "nested file",
+			key:  ReadTimeout,
+			want: Value{Key: ReadTimeout, Value: "1m", Source: SourceFile},
+		},
+		{
+			name: "env over file",
+			key:  Retries,
+			env:  map[string]string{"KPKG_RETRIES": "7"},
+			want: Value{Key: Retries, Value: "7", Source: SourceEnv},
+		},
+		{
+			name:  "flag over env",
+			key:   Retries,
+			env:   map[string]string{"KPKG_RETRIES": "7"},
+			flags: map[string][]string{Retries: {"9"}},
+			want:  Value{Key: Retries, Value: "9", Source: SourceFlag},
+		},
+		{
+			name: "first env var that is set",
+			key:  GithubToken,
+			env:  map[string]string{"GH_TOKEN": "gh"},
+			want: Value{Key: GithubToken, Value: "gh", Source: SourceEnv},
+		},
+		{
+			name: "scalar as list",
+			key:  Rewrite,
+			want: Value{
+				Key:    Rewrite,
+				List:   []string{"https://github.com/=>https://mirror.example.com/github/"},
+				Source: SourceFile,
+			},
+		},
+		{
+			name: "list from env",
+			key:  RewriteRegex,
+			env:  map[string]string{"KPKG_REWRITE_REGEX": "^a=>b ^c=>d"},
+			want: Value{Key: RewriteRegex, List: []string{"^a=>b", "^c=>d"}, Source: SourceEnv},
+		},
+		{
+			name: "empty list",
+			key:  RewriteRegex,
+			want: Value{Key: RewriteRegex, List: []string{}, Source: SourceDefault},
+		},
+		{
+			name: "tool setting",
+			key:  ToolKey("kubectl", ToolVersion),
+			want: Value{Key: "tools.kubectl.version", Value: "1.20.0", Source: SourceFile},
+		},
+		{
+			name: "default tool setting",
+			key:  ToolKey("helm", ToolVersion),
+			want: Value{Key: "tools.helm.version", Value: "latest", Source: SourceDefault},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := load(writeConfig(t, file), envOf(tt.env))
+			if err != nil {
+				t.Fatalf("load() error = %v", err)
+			}
+			for key, values := range tt.flags {
+				if err := c.SetFlag(key, values...); err != nil {
+					t.Fatalf("SetFlag() error = %v", err)
+				}
+			}
+			got, err := c.Get(tt.key)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Get() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+	}{
+		{
+			name: "unknown setting",
+			file: "retry: 3\n",
+		},
+		{
+			name: "unknown tool setting",
+			file: "tools:\n  kubectl:\n    versions: 1.20.0\n",
+		},
+		{
+			name: "invalid value",
+			file: "retries: -1\n",
+		},
+		{
+			name: "invalid rule",
+			file: "mirrors:\n  rewrite:\n    - https://github.com/\n",
+		},
+		{
+			name: "list for a single value",
+			file: "proxy:\n  - http://a\n  - http://b\n",
+		},
+		{
+			name: "invalid yaml",
+			file: "retries: [\n",
+		},
+		{
+			name: "invalid env var",
+			env:  map[string]string{"KPKG_READ_TIMEOUT": "soon"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := load(writeConfig(t, tt.file), envOf(tt.env)); err == nil {
+				t.Errorf("load() expected an error")
+			}
+		})
+	}
+}
+
+func TestConfig_Set(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "kpkg", "config.yaml")
+	c, err := load(p, envOf(nil))
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if err := c.Set(Retries, "4"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set(ReadTimeout, "2m"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set(Rewrite, "a=>b", "c=>d"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := c.Set(ToolKey("kubectl", ToolVersion), "1.20.0"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	for _, invalid := range [][]string{
+		{Retries, "many"},
+		{Retries, "1", "2"},
+		{"retry", "1"},
+		{Rewrite, "a"},
+	} {
+		if err := c.Set(invalid[0], invalid[1:]...); err == nil {
+			t.Errorf("Set(%v) expected an error", invalid)
+		}
+	}
+
+	// the settings are read back from the file
+	c, err = load(p, envOf(nil))
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if got := c.GetUint(Retries); got != 4 {
+		t.Errorf("GetUint() got = %d, want 4", got)
+	}
+	if got := c.GetDuration(ReadTimeout); got != 2*time.Minute {
+		t.Errorf("GetDuration() got = %s, want 2m", got)
+	}
+	if got := c.GetStringSlice(Rewrite); !reflect.DeepEqual(got, []string{"a=>b", "c=>d"}) {
+		t.Errorf("GetStringSlice() got = %v", got)
+	}
+	if got := c.GetString(ToolKey("kubectl", ToolVersion)); got != "1.20.0" {
+		t.Errorf("GetString() got = %s, want 1.20.0", got)
+	}
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// numbers are written unquoted
+	if !strings.Contains(string(b), "retries: 4\n") {
+		t.Errorf("config file does not hold retries: 4, got:\n%s", b)
+	}
+}
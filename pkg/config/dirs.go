@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// Dirs are the dirs that kpkg keeps its state in
+type Dirs struct {
+	// Root holds the installed versions of binaries, and the bin dir linking to them
+	Root string
+	// Cache holds downloaded artifacts, which can be downloaded again
+	Cache string
+	// Index holds the local index of versions, which can be listed again
+	Index string
+}
+
+// RootDirs returns the dirs of the root layout, where everything is kept in root.
+// It is used when the root is set, and was the only layout before kpkg followed XDG
+func RootDirs(root string) Dirs {
+	return Dirs{
+		Root:  root,
+		Cache: filepath.Join(root, "cache"),
+		Index: filepath.Join(root, "index"),
+	}
+}
+
+// XDGDirs returns the dirs of the XDG layout, used when the root is not set. Binaries are
+// installed in $XDG_DATA_HOME/kpkg, which defaults to ~/.local/share/kpkg. Artifacts and the index
+// are kept in $XDG_CACHE_HOME/kpkg, which defaults to ~/.cache/kpkg
+func XDGDirs() (Dirs, error) {
+	return xdgDirs(os.Getenv)
+}
+
+func xdgDirs(getenv func(string) string) (Dirs, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return Dirs{}, err
+	}
+	dataHome := getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	cacheHome := getenv("XDG_CACHE_HOME")
+	if cacheHome == "" {
+		cacheHome = filepath.Join(home, ".cache")
+	}
+	return Dirs{
+		Root:  filepath.Join(dataHome, "kpkg"),
+		Cache: filepath.Join(cacheHome, "kpkg", "artifacts"),
+		Index: filepath.Join(cacheHome, "kpkg", "index"),
+	}, nil
+}
+
+// LegacyRoot returns ~/.kpkg, the root that kpkg used before it followed XDG
+func LegacyRoot() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".kpkg"), nil
+}
+
+// NeedsMigration checks if the legacy root is a dir that was not migrated to dirs yet
+func NeedsMigration(legacyRoot string, dirs Dirs) bool {
+	info, err := os.Lstat(legacyRoot)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+	_, err = os.Stat(dirs.Root)
+	return os.IsNotExist(err)
+}
+
+// Migrate moves the legacy root to the XDG dirs, once. The installed versions are moved to
+// dirs.Root, and the bin dir is linked to them again. The cache and the index are moved to their
+// dirs, or dropped if they can't be moved, since they are rebuilt as needed. Finally, the legacy
+// root is replaced with a symlink to dirs.Root, so that PATH entries pointing into it keep working
+func Migrate(legacyRoot string, dirs Dirs) error {
+	if err := os.MkdirAll(filepath.Dir(dirs.Root), os.ModePerm); err != nil {
+		return err
+	}
+	if err := os.Rename(legacyRoot, dirs.Root); err != nil {
+		return fmt.Errorf("could not move %s to %s: %w", legacyRoot, dirs.Root, err)
+	}
+
+	for _, d := range []struct{ from, to string }{
+		{filepath.Join(dirs.Root, "cache"), dirs.Cache},
+		{filepath.Join(dirs.Root, "index"), dirs.Index},
+	} {
+		if _, err := os.Stat(d.from); err != nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(d.to), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.Rename(d.from, d.to); err != nil {
+			if err := os.RemoveAll(d.from); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the links folder holds the links to the linked versions while shims are enabled
+	for _, d := range []string{"bin", "links"} {
+		if err := relink(filepath.Join(dirs.Root, d), legacyRoot, dirs.Root); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(dirs.Root, legacyRoot)
+}
+
+// relink points the symlinks in binPath that point into the old root to the same paths in
+// the new root
+func relink(binPath, oldRoot, newRoot string) error {
+	entries, err := ioutil.ReadDir(binPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	prefix := oldRoot + string(os.PathSeparator)
+	for _, entry := range entries {
+		if entry.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		linkPath := filepath.Join(binPath, entry.Name())
+		target, err := os.Readlink(linkPath)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(target, prefix) {
+			continue
+		}
+		tmpLinkPath := linkPath + ".relink"
+		if err := os.Symlink(filepath.Join(newRoot, target[len(prefix):]), tmpLinkPath); err != nil {
+			return err
+		}
+		if err := os.Rename(tmpLinkPath, linkPath); err != nil {
+			_ = os.Remove(tmpLinkPath)
+			return err
+		}
+	}
+	return nil
+}
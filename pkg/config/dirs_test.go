@@ -0,0 +1,89 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_xdgDirs(t *testing.T) {
+	env := map[string]string{"XDG_DATA_HOME": "/data", "XDG_CACHE_HOME": "/cache"}
+	dirs, err := xdgDirs(envOf(env))
+	if err != nil {
+		t.Fatalf("xdgDirs() error = %v", err)
+	}
+	want := Dirs{
+		Root:  filepath.Join("/data", "kpkg"),
+		Cache: filepath.Join("/cache", "kpkg", "artifacts"),
+		Index: filepath.Join("/cache", "kpkg", "index"),
+	}
+	if dirs != want {
+		t.Errorf("xdgDirs() got = %+v, want %+v", dirs, want)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	tmp := t.TempDir()
+	legacyRoot := filepath.Join(tmp, ".kpkg")
+	dirs := Dirs{
+		Root:  filepath.Join(tmp, "data", "kpkg"),
+		Cache: filepath.Join(tmp, "cache", "kpkg", "artifacts"),
+		Index: filepath.Join(tmp, "cache", "kpkg", "index"),
+	}
+
+	files := []string{
+		filepath.Join("helm", "3.5.2", "helm"),
+		filepath.Join("cache", "artifact"),
+		filepath.Join("index", "helm.json"),
+	}
+	for _, f := range files {
+		p := filepath.Join(legacyRoot, f)
+		if err := os.MkdirAll(filepath.Dir(p), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(f), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := CreateRoot(legacyRoot); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(
+		filepath.Join(legacyRoot, "helm", "3.5.2", "helm"),
+		filepath.Join(legacyRoot, "bin", "helm"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if !NeedsMigration(legacyRoot, dirs) {
+		t.Fatalf("NeedsMigration() got = false, want true")
+	}
+	if err := Migrate(legacyRoot, dirs); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	if NeedsMigration(legacyRoot, dirs) {
+		t.Errorf("NeedsMigration() got = true after migrating")
+	}
+
+	for _, p := range []string{
+		filepath.Join(dirs.Root, "helm", "3.5.2", "helm"),
+		filepath.Join(dirs.Cache, "artifact"),
+		filepath.Join(dirs.Index, "helm.json"),
+	} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s was not migrated: %v", p, err)
+		}
+	}
+	target, err := os.Readlink(filepath.Join(dirs.Root, "bin", "helm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dirs.Root, "helm", "3.5.2", "helm"); target != want {
+		t.Errorf("bin/helm links to %s, want %s", target, want)
+	}
+	// the legacy root links to the new root, for PATHs that include it
+	if _, err := os.Stat(filepath.Join(legacyRoot, "bin", "helm")); err != nil {
+		t.Errorf("legacy root does not link to the new root: %v", err)
+	}
+}
@@ -12,9 +12,14 @@ func CreatePath(basePath string) (string, error) {
 		return "", err
 	}
 
-	rootDirPath := filepath.Join(basePath, ".kpkg")
+	return CreateRoot(filepath.Join(basePath, ".kpkg"))
+}
+
+// CreateRoot sets up the kpkg root at rootDirPath, along with its bin folder,
+// if not setup already
+func CreateRoot(rootDirPath string) (string, error) {
 	binPath := filepath.Join(rootDirPath, "bin")
-	if err = os.MkdirAll(binPath, os.ModePerm); err != nil {
+	if err := os.MkdirAll(binPath, os.ModePerm); err != nil {
 		return "", err
 	}
 
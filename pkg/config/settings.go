@@ -0,0 +1,222 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spachava753/kpkg/pkg/download"
+)
+
+// Keys of the settings, as they are nested in the config file
+const (
+	Root                = "root"
+	Retries             = "retries"
+	MaxVersions         = "maxVersions"
+	Quiet               = "quiet"
+	ConnectTimeout      = "timeouts.connect"
+	TLSHandshakeTimeout = "timeouts.tlsHandshake"
+	ReadTimeout         = "timeouts.read"
+	LockTimeout         = "timeouts.lock"
+	CommandTimeout      = "timeouts.command"
+	Proxy               = "proxy"
+	CABundle            = "caBundle"
+	InsecureSkipVerify  = "insecureSkipVerify"
+	GithubToken         = "github.token"
+	GithubApiUrl        = "github.apiUrl"
+	Rewrite             = "mirrors.rewrite"
+	RewriteRegex        = "mirrors.rewriteRegex"
+	CacheMaxSize        = "cache.maxSize"
+	IndexTTL            = "index.ttl"
+)
+
+// Keys of the per tool settings, see ToolKey
+const (
+	ToolVersion   = "version"
+	ToolCosignKey = "cosignKey"
+	ToolHold      = "hold"
+)
+
+// toolsKey is the key that per tool settings are nested under
+const toolsKey = "tools"
+
+// ToolKey returns the key of a per tool setting, like tools.kubectl.version
+func ToolKey(tool, setting string) string {
+	return toolsKey + "." + tool + "." + setting
+}
+
+// Kind is the type of the value of a setting
+type Kind int
+
+const (
+	String Kind = iota
+	// Path is a string, with a leading ~ expanded to the home dir
+	Path
+	Uint
+	Bool
+	Duration
+	// Size is a number of bytes, like 500MB
+	Size
+	Url
+	// RewriteRules is a list of rules, written as prefix=>replacement
+	RewriteRules
+	// RewriteRegexRules is a list of rules, written as regexp=>replacement
+	RewriteRegexRules
+)
+
+// IsList checks if the values of the kind are lists
+func (k Kind) IsList() bool {
+	return k == RewriteRules || k == RewriteRegexRules
+}
+
+// validate checks if v is a valid value of the kind
+func (k Kind) validate(v string) error {
+	var err error
+	switch k {
+	case Uint:
+		_, err = strconv.ParseUint(v, 10, 0)
+	case Bool:
+		_, err = strconv.ParseBool(v)
+	case Duration:
+		_, err = time.ParseDuration(v)
+	case Size:
+		_, err = download.ParseSize(v)
+	case Url:
+		if v != "" {
+			_, err = url.Parse(v)
+		}
+	case RewriteRules, RewriteRegexRules:
+		_, err = download.ParseRewriteRule(v, k == RewriteRegexRules)
+	}
+	return err
+}
+
+// Setting describes a setting of kpkg
+type Setting struct {
+	Key string
+	// Env are the env vars that set the setting, in order of precedence.
+	// The values of list settings are separated by whitespace
+	Env     []string
+	Default string
+	Kind    Kind
+	Desc    string
+	// Secret settings are not shown when settings are listed
+	Secret bool
+}
+
+var settings = []Setting{
+	{
+		Key: Root, Env: []string{"KPKG_ROOT"}, Kind: Path,
+		Desc: "dir that binaries and downloads are kept in, instead of the XDG dirs",
+	},
+	{
+		Key: Retries, Env: []string{"KPKG_RETRIES"}, Default: "3", Kind: Uint,
+		Desc: "number of times a failed download is retried",
+	},
+	{
+		Key: MaxVersions, Env: []string{"KPKG_MAX_VERSIONS"}, Default: "20", Kind: Uint,
+		Desc: "max number of versions to list or search through",
+	},
+	{
+		Key: Quiet, Env: []string{"KPKG_QUIET"}, Default: "false", Kind: Bool,
+		Desc: "do not report the progress of downloads and extractions",
+	},
+	{
+		Key: ConnectTimeout, Env: []string{"KPKG_CONNECT_TIMEOUT"}, Default: "30s", Kind: Duration,
+		Desc: "how long connecting to a server may take",
+	},
+	{
+		Key: TLSHandshakeTimeout, Env: []string{"KPKG_TLS_HANDSHAKE_TIMEOUT"}, Default: "10s",
+		Kind: Duration,
+		Desc: "how long the TLS handshake with a server may take",
+	},
+	{
+		Key: ReadTimeout, Env: []string{"KPKG_READ_TIMEOUT"}, Default: "30s", Kind: Duration,
+		Desc: "how long to wait for a server to respond, or to send more data of a download",
+	},
+	{
+		Key: LockTimeout, Env: []string{"KPKG_LOCK_TIMEOUT"}, Default: "1m", Kind: Duration,
+		Desc: "how long to wait for other kpkg processes to finish modifying the same binary",
+	},
+	{
+		Key: CommandTimeout, Env: []string{"KPKG_TIMEOUT"}, Default: "0s", Kind: Duration,
+		Desc: "give up if a command takes longer than this, 0 for no timeout",
+	},
+	{
+		Key: Proxy, Env: []string{"KPKG_PROXY"}, Kind: Url,
+		Desc: "url of the proxy to send requests through, instead of HTTPS_PROXY and HTTP_PROXY. Hosts in NO_PROXY still bypass it",
+	},
+	{
+		Key: CABundle, Env: []string{"KPKG_CA_BUNDLE"}, Kind: Path,
+		Desc: "path to a PEM file of CA certificates to trust, on top of the system certificates",
+	},
+	{
+		Key: InsecureSkipVerify, Env: []string{"KPKG_INSECURE_SKIP_VERIFY"}, Default: "false",
+		Kind: Bool,
+		Desc: "do not verify TLS certificates. This is insecure, only use it to debug TLS issues",
+	},
+	{
+		Key: GithubToken, Env: []string{"GITHUB_TOKEN", "GH_TOKEN"}, Kind: String, Secret: true,
+		Desc: "token to authenticate to the GitHub API with, which raises its rate limit",
+	},
+	{
+		Key: GithubApiUrl, Env: []string{"KPKG_GITHUB_API_URL"}, Kind: Url,
+		Desc: "base url of the GitHub API to list versions from",
+	},
+	{
+		Key: Rewrite, Env: []string{"KPKG_REWRITE"}, Kind: RewriteRules,
+		Desc: "rewrite download urls starting with a prefix, written as prefix=>replacement",
+	},
+	{
+		Key: RewriteRegex, Env: []string{"KPKG_REWRITE_REGEX"}, Kind: RewriteRegexRules,
+		Desc: "rewrite download urls matching a regular expression, written as regexp=>replacement",
+	},
+	{
+		Key: CacheMaxSize, Env: []string{"KPKG_CACHE_MAX_SIZE"}, Default: "2GiB", Kind: Size,
+		Desc: "size that the cache of downloaded artifacts is capped at",
+	},
+	{
+		Key: IndexTTL, Env: []string{"KPKG_INDEX_TTL"}, Default: "24h", Kind: Duration,
+		Desc: "how long the local index of versions is used before versions are listed again",
+	},
+}
+
+var toolSettings = []Setting{
+	{
+		Key: ToolVersion, Default: "latest", Kind: String,
+		Desc: "version that is installed when no version is given",
+	},
+	{
+		Key: ToolCosignKey, Kind: Path,
+		Desc: "path to the public key trusted to sign the artifacts of the tool with cosign",
+	},
+	{
+		Key: ToolHold, Default: "false", Kind: Bool,
+		Desc: "keep the linked version of the tool, instead of upgrading it with kpkg upgrade",
+	},
+}
+
+// Settings returns the settings of kpkg, other than per tool settings
+func Settings() []Setting {
+	return append([]Setting{}, settings...)
+}
+
+// Describe returns the setting with the key, including per tool settings
+func Describe(key string) (Setting, error) {
+	for _, s := range settings {
+		if s.Key == key {
+			return s, nil
+		}
+	}
+	if parts := strings.Split(key, "."); len(parts) == 3 && parts[0] == toolsKey && parts[1] != "" {
+		for _, s := range toolSettings {
+			if s.Key == parts[2] {
+				s.Key = key
+				return s, nil
+			}
+		}
+	}
+	return Setting{}, fmt.Errorf("unknown setting %s", key)
+}
@@ -0,0 +1,461 @@
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCacheMaxSize is the max size of the cache, unless configured otherwise
+const DefaultCacheMaxSize = 2 << 30
+
+const (
+	// cacheBlobsDirName holds the cached files, named after their sha256 digest
+	cacheBlobsDirName = "sha256"
+	// cacheIndexDirName holds an entry per cached url, pointing to the digest of its file
+	cacheIndexDirName = "index"
+)
+
+// Cache is a content addressed store of downloaded files. Files are looked up by the url they
+// were downloaded from, and stored by their sha256 digest, so the same file downloaded from
+// different urls is only stored once
+type Cache struct {
+	dir string
+	// MaxSize is the max total size of the cached files in bytes. Once the cache grows
+	// past it, the least recently used files are evicted. 0 means there is no limit
+	MaxSize int64
+}
+
+// CacheEntry describes a url whose file is cached
+type CacheEntry struct {
+	Url       string    `json:"url"`
+	Sha256    string    `json:"sha256"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastUsed  time.Time `json:"lastUsed"`
+}
+
+// Dir returns the dir the cache is stored in
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// List returns the cached entries, most recently used first
+func (c *Cache) List() ([]CacheEntry, error) {
+	infos, err := ioutil.ReadDir(filepath.Join(c.dir, cacheIndexDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	entries := make([]CacheEntry, 0, len(infos))
+	for _, info := range infos {
+		if info.IsDir() || filepath.Ext(info.Name()) != ".json" {
+			continue
+		}
+		e, err := readCacheEntry(filepath.Join(c.dir, cacheIndexDirName, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+	return entries, nil
+}
+
+// Clean removes every cached file
+func (c *Cache) Clean() error {
+	for _, d := range []string{cacheIndexDirName, cacheBlobsDirName} {
+		if err := os.RemoveAll(filepath.Join(c.dir, d)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prune removes the entries that were not used within the given duration,
+// and returns the removed entries
+func (c *Cache) Prune(olderThan time.Duration) ([]CacheEntry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+	cutoff := time.Now().Add(-olderThan)
+	var pruned []CacheEntry
+	for _, e := range entries {
+		if e.LastUsed.After(cutoff) {
+			continue
+		}
+		if err := c.remove(e, entries); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, e)
+	}
+	return pruned, nil
+}
+
+// Evict removes the least recently used entries until the cache fits in MaxSize,
+// and returns the removed entries
+func (c *Cache) Evict() ([]CacheEntry, error) {
+	if c.MaxSize <= 0 {
+		return nil, nil
+	}
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	// entries with the same digest share a file, which is used as recently as the most
+	// recently used of those entries
+	var total int64
+	lastUsed := map[string]time.Time{}
+	sizes := map[string]int64{}
+	for _, e := range entries {
+		if _, ok := sizes[e.Sha256]; !ok {
+			total += e.Size
+			sizes[e.Sha256] = e.Size
+		}
+		if e.LastUsed.After(lastUsed[e.Sha256]) {
+			lastUsed[e.Sha256] = e.LastUsed
+		}
+	}
+	digests := make([]string, 0, len(sizes))
+	for d := range sizes {
+		digests = append(digests, d)
+	}
+	sort.Slice(digests, func(i, j int) bool {
+		return lastUsed[digests[i]].Before(lastUsed[digests[j]])
+	})
+
+	var evicted []CacheEntry
+	for _, d := range digests {
+		if total <= c.MaxSize {
+			break
+		}
+		for _, e := range entries {
+			if e.Sha256 != d {
+				continue
+			}
+			if err := c.remove(e, entries); err != nil {
+				return evicted, err
+			}
+			evicted = append(evicted, e)
+		}
+		total -= sizes[d]
+	}
+	return evicted, nil
+}
+
+// remove removes the entry, and its file if no other entry points to it
+func (c *Cache) remove(e CacheEntry, entries []CacheEntry) error {
+	if err := os.Remove(c.indexPath(e.Url)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, other := range entries {
+		if other.Url != e.Url && other.Sha256 == e.Sha256 {
+			if _, err := os.Stat(c.indexPath(other.Url)); err == nil {
+				return nil
+			}
+		}
+	}
+	if err := os.Remove(c.blobPath(e.Sha256)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// lookup returns the path of the cached file for the url, and the sha256 digest recorded for it.
+// If expected is not empty, a file is only returned if it has the expected sha256 digest, which
+// can also be found under a different url
+func (c *Cache) lookup(u, expected string) (string, string, bool) {
+	e, err := readCacheEntry(c.indexPath(u))
+	if expected != "" && (err != nil || !strings.EqualFold(e.Sha256, expected)) {
+		// the expected file may have been cached from a different url
+		e = CacheEntry{Url: u, Sha256: strings.ToLower(expected), CreatedAt: time.Now()}
+	} else if err != nil {
+		return "", "", false
+	}
+	digest := e.Sha256
+	info, err := os.Stat(c.blobPath(digest))
+	if err != nil {
+		return "", "", false
+	}
+	e.Size = info.Size()
+	e.LastUsed = time.Now()
+	// failing to record the use only makes the entry more likely to be evicted
+	_ = c.writeEntry(e)
+	return c.blobPath(digest), digest, true
+}
+
+// discard removes a cached file whose contents no longer match its digest, along with the
+// entry of the url it was looked up by
+func (c *Cache) discard(u, digest string) error {
+	if err := os.Remove(c.indexPath(u)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(c.blobPath(digest)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// store copies the file at p into the cache as the file of the url, and returns its digest.
+// If expected is not empty, the file is only stored if it has the expected sha256 digest
+func (c *Cache) store(u, p, expected string) (string, error) {
+	blobsPath := filepath.Join(c.dir, cacheBlobsDirName)
+	if err := os.MkdirAll(blobsPath, 0755); err != nil {
+		return "", err
+	}
+	in, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	tmp, err := ioutil.TempFile(blobsPath, ".tmp-")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), in)
+	if e := tmp.Close(); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		return "", err
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+	if expected != "" && !strings.EqualFold(digest, expected) {
+		return digest, fmt.Errorf(
+			"not caching %s, expected sha256 %s, got %s", u, expected, digest,
+		)
+	}
+	// the file is renamed into place, so that other processes never see a partial file
+	if err := os.Rename(tmp.Name(), c.blobPath(digest)); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return digest, c.writeEntry(CacheEntry{
+		Url:       u,
+		Sha256:    digest,
+		Size:      size,
+		CreatedAt: now,
+		LastUsed:  now,
+	})
+}
+
+func (c *Cache) writeEntry(e CacheEntry) error {
+	indexPath := filepath.Join(c.dir, cacheIndexDirName)
+	if err := os.MkdirAll(indexPath, 0755); err != nil {
+		return err
+	}
+	contents, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(indexPath, ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(contents)
+	if e := tmp.Close(); e != nil && err == nil {
+		err = e
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.indexPath(e.Url))
+}
+
+func (c *Cache) indexPath(u string) string {
+	key := sha256.Sum256([]byte(u))
+	return filepath.Join(c.dir, cacheIndexDirName, hex.EncodeToString(key[:])+".json")
+}
+
+func (c *Cache) blobPath(digest string) string {
+	return filepath.Join(c.dir, cacheBlobsDirName, strings.ToLower(digest))
+}
+
+func readCacheEntry(p string) (CacheEntry, error) {
+	var e CacheEntry
+	contents, err := ioutil.ReadFile(p)
+	if err != nil {
+		return e, err
+	}
+	if err := json.Unmarshal(contents, &e); err != nil {
+		return e, fmt.Errorf("could not parse cache entry %s: %w", p, err)
+	}
+	return e, nil
+}
+
+// MakeCache creates a cache stored in dir, creating the dir if it does not exist
+func MakeCache(dir string, maxSize int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{
+		dir:     dir,
+		MaxSize: maxSize,
+	}, nil
+}
+
+type sha256Key struct{}
+
+// WithSha256 returns a copy of ctx that tells file fetchers the expected sha256 digest of
+// the file being fetched. The cache uses it to serve files by their contents, and to avoid
+// storing corrupt files
+func WithSha256(ctx context.Context, digest string) context.Context {
+	return context.WithValue(ctx, sha256Key{}, digest)
+}
+
+// expectedSha256 returns the digest set with WithSha256, or an empty string if there is none
+func expectedSha256(ctx context.Context) string {
+	digest, _ := ctx.Value(sha256Key{}).(string)
+	return digest
+}
+
+type cacheFileFetcher struct {
+	cache *Cache
+	// cached files are copied into this dir, so that callers can remove them
+	// just like downloaded files
+	filePath string
+	// print logs to file, if not nil
+	out io.Writer
+	FileFetcher
+}
+
+func (c *cacheFileFetcher) FetchFile(ctx context.Context, u string) (string, error) {
+	expected := expectedSha256(ctx)
+	if cached, digest, ok := c.cache.lookup(u, expected); ok {
+		p, err := c.copyOut(u, cached, digest)
+		if err == nil {
+			c.print(fmt.Sprintf("using cached file for %s\n", u))
+			return p, nil
+		}
+		c.print(fmt.Sprintf("could not read cached file for %s: %s\n", u, err))
+	}
+
+	s, err := c.FileFetcher.FetchFile(ctx, u)
+	if err != nil {
+		return s, err
+	}
+	// a broken cache should never fail a download
+	if _, err := c.cache.store(u, s, expected); err != nil {
+		c.print(fmt.Sprintf("warning: could not cache %s: %s\n", u, err))
+		return s, nil
+	}
+	if _, err := c.cache.Evict(); err != nil {
+		c.print(fmt.Sprintf("warning: could not evict cached files: %s\n", err))
+	}
+	return s, nil
+}
+
+// copyOut copies the cached file for the url to where the wrapped file fetcher would
+// have downloaded it. The copy is checked against the digest the file was stored with,
+// and a cached file that was changed since is discarded, so that it is downloaded again
+func (c *cacheFileFetcher) copyOut(u, cached, digest string) (s string, err error) {
+	parsedUrl, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	in, err := os.Open(cached)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	p := filepath.Join(c.filePath, filepath.Base(parsedUrl.Path))
+	out, err := os.Create(p)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if e := out.Close(); e != nil && err == nil {
+			err = e
+		}
+		if err != nil {
+			_ = os.Remove(p)
+		}
+	}()
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(out, h), in); err != nil {
+		return "", err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(got, digest) {
+		if e := c.cache.discard(u, digest); e != nil {
+			c.print(fmt.Sprintf("warning: could not remove corrupt cached file %s: %s\n", cached, e))
+		}
+		return "", fmt.Errorf("cached file has sha256 %s, expected %s", got, digest)
+	}
+	return p, nil
+}
+
+func (c *cacheFileFetcher) print(message string) {
+	if c.out != nil {
+		_, _ = fmt.Fprint(c.out, message)
+	}
+}
+
+// MakeCacheFileFetcher creates a file fetcher that serves files from the cache when possible,
+// and caches the files fetched by f. Files served from the cache are copied into filePath
+func MakeCacheFileFetcher(
+	cache *Cache, filePath string, out *os.File, f FileFetcher,
+) (FileFetcher, error) {
+	if cache == nil || filePath == "" || f == nil {
+		return nil, fmt.Errorf("params cannot be nil")
+	}
+	c := &cacheFileFetcher{
+		cache:       cache,
+		filePath:    filePath,
+		FileFetcher: f,
+	}
+	if out != nil {
+		c.out = out
+	}
+	return c, nil
+}
+
+// ParseSize parses a size in bytes, optionally followed by a unit like KB, MiB or G.
+// Units are powers of 1024, so 1KB is 1024 bytes
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	number, unit := s, ""
+	if i >= 0 {
+		number, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	multipliers := map[string]float64{
+		"": 1, "b": 1,
+		"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10,
+		"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20,
+		"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30,
+		"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40,
+	}
+	m, ok := multipliers[strings.ToLower(unit)]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q, unknown unit %s", s, unit)
+	}
+	return int64(n * m), nil
+}
@@ -0,0 +1,221 @@
+package download
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const helloSha256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+// countingFileFetcher writes fixed contents for every url, and counts the fetches
+type countingFileFetcher struct {
+	dir      string
+	contents string
+	count    int
+}
+
+func (c *countingFileFetcher) FetchFile(_ context.Context, u string) (string, error) {
+	c.count++
+	p := filepath.Join(c.dir, filepath.Base(u))
+	return p, ioutil.WriteFile(p, []byte(c.contents), os.ModePerm)
+}
+
+func makeTestCache(t *testing.T, maxSize int64) *Cache {
+	c, err := MakeCache(filepath.Join(t.TempDir(), "cache"), maxSize)
+	if err != nil {
+		t.Fatalf("could not create cache: %s", err)
+	}
+	return c
+}
+
+func Test_cacheFileFetcher_FetchFile(t *testing.T) {
+	tests := []struct {
+		name string
+		// urls are fetched in order, with the expected digest, if any
+		urls      []string
+		expected  string
+		contents  string
+		wantCount int
+	}{
+		{
+			name:      "same url is fetched once",
+			urls:      []string{"https://some.url/a/hello.txt", "https://some.url/a/hello.txt"},
+			contents:  "hello",
+			wantCount: 1,
+		},
+		{
+			name:      "different urls are fetched separately",
+			urls:      []string{"https://some.url/a/hello.txt", "https://some.url/b/hello.txt"},
+			contents:  "hello",
+			wantCount: 2,
+		},
+		{
+			name:      "known digest is served from any url",
+			urls:      []string{"https://some.url/a/hello.txt", "https://mirror.url/a/hello.txt"},
+			expected:  helloSha256,
+			contents:  "hello",
+			wantCount: 1,
+		},
+		{
+			name:      "mismatching file is not cached",
+			urls:      []string{"https://some.url/a/hello.txt", "https://some.url/a/hello.txt"},
+			expected:  helloSha256,
+			contents:  "corrupt",
+			wantCount: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := &countingFileFetcher{dir: t.TempDir(), contents: tt.contents}
+			f, err := MakeCacheFileFetcher(makeTestCache(t, 0), t.TempDir(), nil, inner)
+			if err != nil {
+				t.Fatalf("could not create file fetcher: %s", err)
+			}
+			ctx := context.Background()
+			if tt.expected != "" {
+				ctx = WithSha256(ctx, tt.expected)
+			}
+			for _, u := range tt.urls {
+				p, err := f.FetchFile(ctx, u)
+				if err != nil {
+					t.Fatalf("FetchFile() error = %v, expected nil", err)
+				}
+				contents, err := ioutil.ReadFile(p)
+				if err != nil {
+					t.Fatalf("could not read fetched file: %s", err)
+				}
+				if string(contents) != tt.contents {
+					t.Errorf("FetchFile() contents = %s, want %s", contents, tt.contents)
+				}
+			}
+			if inner.count != tt.wantCount {
+				t.Errorf("fetched %d times, want %d", inner.count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func Test_cacheFileFetcher_FetchFile_Tampered(t *testing.T) {
+	c := makeTestCache(t, 0)
+	inner := &countingFileFetcher{dir: t.TempDir(), contents: "hello"}
+	f, err := MakeCacheFileFetcher(c, t.TempDir(), nil, inner)
+	if err != nil {
+		t.Fatalf("could not create file fetcher: %s", err)
+	}
+	u := "https://some.url/a/hello.txt"
+	if _, err := f.FetchFile(context.Background(), u); err != nil {
+		t.Fatalf("FetchFile() error = %v, expected nil", err)
+	}
+
+	// the cached file is changed behind the cache's back, so it must not be served
+	if err := ioutil.WriteFile(c.blobPath(helloSha256), []byte("evil"), os.ModePerm); err != nil {
+		t.Fatalf("could not write cached file: %s", err)
+	}
+	p, err := f.FetchFile(context.Background(), u)
+	if err != nil {
+		t.Fatalf("FetchFile() error = %v, expected nil", err)
+	}
+	contents, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatalf("could not read fetched file: %s", err)
+	}
+	if string(contents) != "hello" {
+		t.Errorf("FetchFile() contents = %s, want hello", contents)
+	}
+	if inner.count != 2 {
+		t.Errorf("fetched %d times, want 2", inner.count)
+	}
+	// the file was cached again with its right contents
+	if _, err := f.FetchFile(context.Background(), u); err != nil {
+		t.Fatalf("FetchFile() error = %v, expected nil", err)
+	}
+	if inner.count != 2 {
+		t.Errorf("fetched %d times, want 2", inner.count)
+	}
+}
+
+func TestCache_Evict(t *testing.T) {
+	c := makeTestCache(t, 12)
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		p := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(p, []byte("hello"+name), os.ModePerm); err != nil {
+			t.Fatalf("could not write file: %s", err)
+		}
+		if _, err := c.store("https://some.url/"+name, p, ""); err != nil {
+			t.Fatalf("store() error = %v, expected nil", err)
+		}
+		// make sure the entries are ordered by when they were last used
+		time.Sleep(10 * time.Millisecond)
+	}
+	// using a makes b the least recently used
+	if _, _, ok := c.lookup("https://some.url/a", ""); !ok {
+		t.Fatalf("expected a to be cached")
+	}
+
+	evicted, err := c.Evict()
+	if err != nil {
+		t.Fatalf("Evict() error = %v, expected nil", err)
+	}
+	if len(evicted) != 1 || evicted[0].Url != "https://some.url/b" {
+		t.Errorf("Evict() = %+v, expected b to be evicted", evicted)
+	}
+	if _, _, ok := c.lookup("https://some.url/b", ""); ok {
+		t.Errorf("expected b to be removed from the cache")
+	}
+}
+
+func TestCache_Prune(t *testing.T) {
+	c := makeTestCache(t, 0)
+	p := filepath.Join(t.TempDir(), "a")
+	if err := ioutil.WriteFile(p, []byte("hello"), os.ModePerm); err != nil {
+		t.Fatalf("could not write file: %s", err)
+	}
+	if _, err := c.store("https://some.url/a", p, ""); err != nil {
+		t.Fatalf("store() error = %v, expected nil", err)
+	}
+
+	if pruned, err := c.Prune(time.Hour); err != nil || len(pruned) != 0 {
+		t.Errorf("Prune() = %v, %v, expected nothing to be pruned", pruned, err)
+	}
+	if pruned, err := c.Prune(0); err != nil || len(pruned) != 1 {
+		t.Errorf("Prune() = %v, %v, expected the entry to be pruned", pruned, err)
+	}
+	if entries, err := c.List(); err != nil || len(entries) != 0 {
+		t.Errorf("List() = %v, %v, expected an empty cache", entries, err)
+	}
+	if _, err := os.Stat(c.blobPath(helloSha256)); !os.IsNotExist(err) {
+		t.Errorf("expected cached file to be removed, got %v", err)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    int64
+		wantErr bool
+	}{
+		{s: "100", want: 100},
+		{s: "2KB", want: 2048},
+		{s: "1.5 GiB", want: 3 << 29},
+		{s: "500m", want: 500 << 20},
+		{s: "10 parsecs", wantErr: true},
+		{s: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseSize(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSize() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,38 +1,49 @@
 package download
 
 import (
+	"context"
 	"fmt"
 	"io"
-	"math/rand"
+	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
-	"time"
+	"strings"
+	"sync"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
 )
 
 // FileFetcher is an interface responsible for fetching files from a url
 type FileFetcher interface {
-	// FetchFile takes a url returns to location of the donwloaded file
-	FetchFile(url string) (string, error)
+	// FetchFile takes a url returns to location of the donwloaded file.
+	// Fetching stops early if ctx is done
+	FetchFile(ctx context.Context, url string) (string, error)
 }
 
 type basicFileFetcher struct {
 	filePath string
 	client   *http.Client
+	// progress of downloads is reported here, if not nil
+	progress ProgressReporter
+	// partials holds the validators of partial downloads that can be resumed,
+	// keyed by the path of the partial file
+	partials sync.Map
 }
 
-func (b *basicFileFetcher) FetchFile(urlStr string) (s string, err error) {
-	var res *http.Response
-	res, err = b.client.Get(urlStr)
+func (b *basicFileFetcher) FetchFile(ctx context.Context, urlStr string) (s string, err error) {
+	parsedUrl, err := url.Parse(urlStr)
 	if err != nil {
-		return s, err
-	}
-	if res == nil {
-		return "", fmt.Errorf("response object is nil")
+		return "", err
 	}
-	if res.Body == nil {
-		return "", fmt.Errorf("response body is nil")
+	fLoc := filepath.Join(b.filePath, filepath.Base(parsedUrl.Path))
+	// the file is downloaded next to its final location, and only moved there once complete
+	partPath := fLoc + ".part"
+
+	res, offset, err := b.request(ctx, urlStr, partPath)
+	if err != nil {
+		return "", err
 	}
 
 	// defer closing the body, and handle the error if pops up
@@ -42,59 +53,169 @@ func (b *basicFileFetcher) FetchFile(urlStr string) (s string, err error) {
 		}
 	}()
 
-	if res.StatusCode != http.StatusOK {
-		err = fmt.Errorf("incorrect status for downloading tool: %d", res.StatusCode)
-		return
+	// remember how to resume the download if it fails, when the server supports it
+	validator := resumeValidator(res)
+	if validator != "" {
+		b.partials.Store(partPath, validator)
+	} else {
+		b.partials.Delete(partPath)
 	}
 
-	// create a temporary file
-	parsedUrl, err := url.Parse(urlStr)
+	var f *os.File
+	operation := "downloading"
+	if offset > 0 {
+		operation = "resuming"
+		f, err = os.OpenFile(partPath, os.O_WRONLY|os.O_APPEND, 0)
+	} else {
+		f, err = os.Create(partPath)
+	}
 	if err != nil {
 		return "", err
 	}
-	fLoc := filepath.Join(b.filePath, filepath.Base(parsedUrl.Path))
-	var f *os.File
-	f, err = os.Create(fLoc)
+
+	p := startProgress(b.progress, operation, filepath.Base(fLoc), res.ContentLength)
+	defer p.Close()
+	n, err := io.Copy(io.MultiWriter(f, p), res.Body)
+	// a body that ends early is not always reported by the transport, so compare against
+	// the length announced by the server as well
+	if err == nil && res.ContentLength >= 0 && n != res.ContentLength {
+		err = io.ErrUnexpectedEOF
+	}
+	if e := f.Close(); e != nil && err == nil {
+		err = e
+	}
 	if err != nil {
-		return
+		// keep the partial file around to resume from, unless the download was cancelled
+		// or can't be resumed
+		if ctx.Err() != nil || validator == "" {
+			b.partials.Delete(partPath)
+			_ = os.Remove(partPath)
+		}
+		if ctx.Err() != nil {
+			return "", err
+		}
+		return "", &kpkgerr.IncompleteDownloadErr{
+			Url:      urlStr,
+			Expected: res.ContentLength,
+			Actual:   n,
+			Err:      err,
+		}
 	}
 
-	// defer closing the file, and handle the error if pops up
-	defer func() {
-		if f != nil {
-			if e := f.Close(); e != nil && err == nil {
-				err = e
-			}
+	b.partials.Delete(partPath)
+	if err := os.Rename(partPath, fLoc); err != nil {
+		return "", err
+	}
+	return fLoc, nil
+}
+
+// request sends a GET request for the url. If a partial download that can be resumed exists
+// at partPath, only the missing bytes are requested. The returned offset is the size of the
+// partial file that the response continues, or 0 if the response holds the whole file
+func (b *basicFileFetcher) request(
+	ctx context.Context, urlStr, partPath string,
+) (*http.Response, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, urlStr, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	var offset int64
+	if validator, ok := b.partials.Load(partPath); ok {
+		if info, err := os.Stat(partPath); err == nil && info.Size() > 0 {
+			offset = info.Size()
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			// the server sends the whole file instead, if it changed since the partial download
+			req.Header.Set("If-Range", validator.(string))
 		}
-	}()
+	}
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if res == nil {
+		return nil, 0, fmt.Errorf("response object is nil")
+	}
+	if res.Body == nil {
+		return nil, 0, fmt.Errorf("response body is nil")
+	}
 
-	if _, err := io.Copy(f, res.Body); err != nil {
-		return fLoc, err
+	switch {
+	case res.StatusCode == http.StatusOK:
+		return res, 0, nil
+	case res.StatusCode == http.StatusPartialContent && offset > 0 &&
+		contentRangeStart(res.Header.Get("Content-Range")) == offset:
+		return res, offset, nil
+	case offset > 0 && (res.StatusCode == http.StatusPartialContent ||
+		res.StatusCode == http.StatusRequestedRangeNotSatisfiable):
+		// the server did not continue where the partial download ended, so start over
+		_ = res.Body.Close()
+		b.partials.Delete(partPath)
+		if err := os.Remove(partPath); err != nil {
+			return nil, 0, err
+		}
+		return b.request(ctx, urlStr, partPath)
+	}
+	_ = res.Body.Close()
+	return nil, 0, &kpkgerr.HttpStatusErr{Url: urlStr, StatusCode: res.StatusCode}
+}
+
+// resumeValidator returns the value to send in an If-Range header to resume the download
+// of the response, or an empty string if the server does not support resuming it
+func resumeValidator(res *http.Response) string {
+	if res.StatusCode != http.StatusPartialContent &&
+		res.Header.Get("Accept-Ranges") != "bytes" {
+		return ""
+	}
+	// weak etags can't be used to resume downloads
+	if etag := res.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
 	}
+	return res.Header.Get("Last-Modified")
+}
 
-	s = fLoc
-	err = nil
-	return
+// contentRangeStart parses the first byte position of a Content-Range header,
+// like "bytes 100-199/200". It returns -1 if the header can't be parsed
+func contentRangeStart(contentRange string) int64 {
+	var start, end int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/", &start, &end); err != nil {
+		return -1
+	}
+	return start
 }
 
-func MakeBasicFileFetcher(filePath string, client *http.Client) (FileFetcher, error) {
+func MakeBasicFileFetcher(
+	filePath string, client *http.Client, progress ProgressReporter,
+) (FileFetcher, error) {
 	if filePath == "" || client == nil {
 		return nil, fmt.Errorf("params cannot be nil")
 	}
 	return &basicFileFetcher{
 		filePath: filePath,
 		client:   client,
+		progress: progress,
 	}, nil
 }
 
-func MakeFileFetcherTempDir(client *http.Client) (FileFetcher, error) {
+func MakeFileFetcherTempDir(
+	client *http.Client, progress ProgressReporter,
+) (FileFetcher, error) {
 	if client == nil {
 		return nil, fmt.Errorf("params cannot be nil")
 	}
-	randPath := time.Now().Unix() * int64(rand.Uint32())
-	p := filepath.Join(os.TempDir(), "kpkg", fmt.Sprint(randPath))
-	if err := os.MkdirAll(p, 0755); err != nil {
+	p, err := MakeTempDir()
+	if err != nil {
 		return nil, err
 	}
-	return MakeBasicFileFetcher(p, client)
+	return MakeBasicFileFetcher(p, client, progress)
+}
+
+// MakeTempDir creates a fresh dir under the kpkg folder in the os temp dir,
+// to download artifacts into. The caller is responsible for removing it
+func MakeTempDir() (string, error) {
+	tmpPath := filepath.Join(os.TempDir(), "kpkg")
+	if err := os.MkdirAll(tmpPath, 0755); err != nil {
+		return "", err
+	}
+	return ioutil.TempDir(tmpPath, "")
 }
@@ -1,11 +1,20 @@
 package download
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
 	"testing"
+	"time"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
 )
 
 func Test_basicFileFetcher_FetchFile(t *testing.T) {
@@ -19,8 +28,8 @@ func Test_basicFileFetcher_FetchFile(t *testing.T) {
 	fPath := filepath.Join(t.TempDir(), "testResp")
 	defer server.Close()
 
-	b := &basicFileFetcher{fPath, server.Client()}
-	tmpFilePath, err := b.FetchFile(server.URL)
+	b := &basicFileFetcher{filePath: fPath, client: server.Client()}
+	tmpFilePath, err := b.FetchFile(context.Background(), server.URL)
 	if err != nil {
 		t.Errorf("encountered error when fetching file: %s", err)
 	}
@@ -35,3 +44,137 @@ func Test_basicFileFetcher_FetchFile(t *testing.T) {
 		t.Errorf("wanted: %s; got: %s", testResp, string(contents))
 	}
 }
+
+func Test_basicFileFetcher_FetchFile_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Length", "10")
+		_, _ = rw.Write([]byte("hello"))
+		rw.(http.Flusher).Flush()
+		// interrupt the download halfway through
+		cancel()
+		<-req.Context().Done()
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	b := &basicFileFetcher{filePath: dir, client: server.Client()}
+	if _, err := b.FetchFile(ctx, server.URL+"/hello.txt"); !errors.Is(err, context.Canceled) {
+		t.Errorf("FetchFile() error = %v, expected context canceled", err)
+	}
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected partial download to be removed, found %d files", len(entries))
+	}
+}
+
+func Test_basicFileFetcher_FetchFile_Resume(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 10000)
+	modTime := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		// acceptRanges sets whether the server advertises support for range requests
+		acceptRanges bool
+		// changeEtag changes the etag of the file after the first request
+		changeEtag bool
+		wantRanges []string
+	}{
+		{
+			name:         "resumes where the download was cut off",
+			acceptRanges: true,
+			wantRanges:   []string{"", "bytes=50000-"},
+		},
+		{
+			name:       "restarts when ranges are not supported",
+			wantRanges: []string{"", ""},
+		},
+		{
+			name:         "restarts when the file changed",
+			acceptRanges: true,
+			changeEtag:   true,
+			wantRanges:   []string{"", "bytes=50000-"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranges []string
+			server := httptest.NewServer(http.HandlerFunc(
+				func(rw http.ResponseWriter, req *http.Request) {
+					ranges = append(ranges, req.Header.Get("Range"))
+					etag := `"v1"`
+					if tt.changeEtag && len(ranges) > 1 {
+						etag = `"v2"`
+					}
+					rw.Header().Set("ETag", etag)
+					if !tt.acceptRanges {
+						// ServeContent always supports ranges, so serve the file directly
+						rw.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+						if len(ranges) == 1 {
+							_, _ = rw.Write(payload[:len(payload)/2])
+							rw.(http.Flusher).Flush()
+							panic(http.ErrAbortHandler)
+						}
+						_, _ = rw.Write(payload)
+						return
+					}
+					if len(ranges) == 1 {
+						// drop the connection halfway through the first download
+						rw.Header().Set("Accept-Ranges", "bytes")
+						rw.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+						_, _ = rw.Write(payload[:len(payload)/2])
+						rw.(http.Flusher).Flush()
+						panic(http.ErrAbortHandler)
+					}
+					http.ServeContent(rw, req, "a.bin", modTime, bytes.NewReader(payload))
+				},
+			))
+			defer server.Close()
+
+			dir := t.TempDir()
+			b := &basicFileFetcher{filePath: dir, client: server.Client()}
+			f, err := MakeRetryFileFetcher(1, os.Stdout, b)
+			if err != nil {
+				t.Fatalf("could not create retry fetcher: %s", err)
+			}
+			p, err := f.FetchFile(context.Background(), server.URL+"/a.bin")
+			if err != nil {
+				t.Fatalf("FetchFile() error = %v, expected nil", err)
+			}
+			contents, err := ioutil.ReadFile(p)
+			if err != nil {
+				t.Fatalf("could not read downloaded file: %s", err)
+			}
+			if !bytes.Equal(contents, payload) {
+				t.Errorf("downloaded %d bytes, which don't match the payload", len(contents))
+			}
+			if !reflect.DeepEqual(ranges, tt.wantRanges) {
+				t.Errorf("requested ranges %q, want %q", ranges, tt.wantRanges)
+			}
+			if _, err := os.Stat(p + ".part"); !os.IsNotExist(err) {
+				t.Errorf("expected partial file to be removed, got %v", err)
+			}
+		})
+	}
+}
+
+func Test_basicFileFetcher_FetchFile_Truncated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Content-Length", "10")
+		_, _ = rw.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	b := &basicFileFetcher{filePath: t.TempDir(), client: server.Client()}
+	_, err := b.FetchFile(context.Background(), server.URL+"/hello.txt")
+	var incompleteErr *kpkgerr.IncompleteDownloadErr
+	if !errors.As(err, &incompleteErr) {
+		t.Fatalf("FetchFile() error = %v, expected incomplete download", err)
+	}
+	if incompleteErr.Expected != 10 || incompleteErr.Actual != 5 {
+		t.Errorf(
+			"expected 5 of 10 bytes to be downloaded, got %d of %d",
+			incompleteErr.Actual, incompleteErr.Expected,
+		)
+	}
+}
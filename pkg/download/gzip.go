@@ -2,6 +2,7 @@ package download
 
 import (
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -12,11 +13,13 @@ import (
 type gzipFileFetcher struct {
 	// print logs to file, if not nil
 	out io.Writer
+	// progress of extractions is reported here, if not nil
+	progress ProgressReporter
 	FileFetcher
 }
 
-func (r *gzipFileFetcher) FetchFile(u string) (string, error) {
-	s, err := r.FileFetcher.FetchFile(u)
+func (r *gzipFileFetcher) FetchFile(ctx context.Context, u string) (string, error) {
+	s, err := r.FileFetcher.FetchFile(ctx, u)
 	if err != nil {
 		return s, err
 	}
@@ -31,7 +34,15 @@ func (r *gzipFileFetcher) FetchFile(u string) (string, error) {
 	if err != nil {
 		return s, err
 	}
-	gzipReader, err := gzip.NewReader(f)
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return s, err
+	}
+	p := startProgress(r.progress, "decompressing", filepath.Base(s), info.Size())
+	defer p.Close()
+
+	gzipReader, err := gzip.NewReader(io.TeeReader(f, p))
 	if err != nil {
 		return s, err
 	}
@@ -56,12 +67,15 @@ func (r *gzipFileFetcher) FetchFile(u string) (string, error) {
 	return fPath, nil
 }
 
-func MakeGzipFileFetcher(out *os.File, f FileFetcher) (FileFetcher, error) {
+func MakeGzipFileFetcher(
+	out *os.File, progress ProgressReporter, f FileFetcher,
+) (FileFetcher, error) {
 	if f == nil {
 		return nil, fmt.Errorf("file fetcher param cannot be nil")
 	}
 	return &gzipFileFetcher{
 		out:         out,
+		progress:    progress,
 		FileFetcher: f,
 	}, nil
 }
@@ -1,6 +1,7 @@
 package download
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -11,7 +12,7 @@ type testGzipFileFetcher struct {
 	gzipFilePath string
 }
 
-func (t testGzipFileFetcher) FetchFile(_ string) (string, error) {
+func (t testGzipFileFetcher) FetchFile(_ context.Context, _ string) (string, error) {
 	return filepath.Abs(t.gzipFilePath)
 }
 
@@ -31,7 +32,7 @@ func Test_gzipFileFetcher_FetchFile_Zip(t *testing.T) {
 		out:         os.Stdout,
 		FileFetcher: &testGzipFileFetcher{gzipFilePath: zipFilePath},
 	}
-	unzippedFilePath, err := zipff.FetchFile("http://some.url")
+	unzippedFilePath, err := zipff.FetchFile(context.Background(), "http://some.url")
 	if err != nil {
 		t.Errorf("expected no error, got: %s", err)
 	}
@@ -66,7 +67,7 @@ func Test_gzipFileFetcher_FetchFile(t *testing.T) {
 		out:         os.Stdout,
 		FileFetcher: &testGzipFileFetcher{gzipFilePath: normFilePath},
 	}
-	unzippedFilePath, err := zipff.FetchFile("http://some.url")
+	unzippedFilePath, err := zipff.FetchFile(context.Background(), "http://some.url")
 	if err != nil {
 		t.Errorf("expected no error, got: %s", err)
 	}
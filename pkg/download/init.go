@@ -3,30 +3,48 @@ package download
 import (
 	"net/http"
 	"os"
-	"time"
 )
 
-func InitFileFetcher() (FileFetcher, error) {
-	// create a file fetcher for binaries to fetch file
-	fileFetcher, err := MakeFileFetcherTempDir(&http.Client{
-		Timeout: time.Second * 10,
-	})
+// InitFileFetcher creates the file fetcher used to download artifacts into tmpPath with
+// transport, retrying failed downloads retries times and reporting the progress of downloads
+// to progress. If cache is not nil, downloads are served from and stored in the cache.
+// Retries and cache hits are logged to out. The returned file fetcher does not unpack
+// archives, see InitUnpacker
+func InitFileFetcher(
+	tmpPath string, transport http.RoundTripper, retries uint, cache *Cache,
+	progress ProgressReporter, out *os.File,
+) (FileFetcher, error) {
+	// create a file fetcher for binaries to fetch file. There is no deadline for a whole
+	// download, since the transport only gives up on downloads that stall
+	fileFetcher, err := MakeBasicFileFetcher(tmpPath, &http.Client{
+		Transport: transport,
+	}, progress)
 	if err != nil {
 		return nil, err
 	}
-	fileFetcher, err = MakeRetryFileFetcher(3, os.Stdout, fileFetcher)
+	fileFetcher, err = MakeRetryFileFetcher(retries, out, fileFetcher)
 	if err != nil {
 		return nil, err
 	}
-	fileFetcher, err = MakeZipFileFetcher(os.Stdout, fileFetcher)
+	if cache == nil {
+		return fileFetcher, nil
+	}
+	return MakeCacheFileFetcher(cache, tmpPath, out, fileFetcher)
+}
+
+// InitUnpacker creates a file fetcher that takes the path of a downloaded artifact,
+// and unzips, decompresses and/or un-tars it, reporting the progress to progress and
+// logging to out
+func InitUnpacker(progress ProgressReporter, out *os.File) (FileFetcher, error) {
+	fileFetcher, err := MakeZipFileFetcher(out, progress, MakeLocalFileFetcher())
 	if err != nil {
 		return nil, err
 	}
-	fileFetcher, err = MakeGzipFileFetcher(os.Stdout, fileFetcher)
+	fileFetcher, err = MakeGzipFileFetcher(out, progress, fileFetcher)
 	if err != nil {
 		return nil, err
 	}
-	fileFetcher, err = MakeTarFileFetcher(os.Stdout, fileFetcher)
+	fileFetcher, err = MakeTarFileFetcher(out, progress, fileFetcher)
 	if err != nil {
 		return nil, err
 	}
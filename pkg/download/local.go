@@ -0,0 +1,85 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// localFileFetcher treats the url passed in as the path of a file that is already on disk.
+// It is meant to be wrapped by the archive file fetchers, so that an artifact that was
+// already downloaded can be unpacked
+type localFileFetcher struct{}
+
+func (l localFileFetcher) FetchFile(ctx context.Context, p string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	info, err := os.Stat(p)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("path %s is a dir", p)
+	}
+	return filepath.Abs(p)
+}
+
+func MakeLocalFileFetcher() FileFetcher {
+	return localFileFetcher{}
+}
+
+// dirFileFetcher serves urls from a dir of pre-downloaded files, instead of downloading them.
+// A url is served by the file in the dir named like the last element of the url's path
+type dirFileFetcher struct {
+	dir string
+	// files are copied into this dir, so that callers can remove them like downloaded files
+	filePath string
+}
+
+func (d dirFileFetcher) FetchFile(ctx context.Context, u string) (s string, err error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	parsedUrl, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	name := path.Base(parsedUrl.Path)
+	in, err := os.Open(filepath.Join(d.dir, name))
+	if err != nil {
+		return "", fmt.Errorf("could not find %s for url %s in %s: %w", name, u, d.dir, err)
+	}
+	defer in.Close()
+
+	p := filepath.Join(d.filePath, name)
+	out, err := os.Create(p)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if e := out.Close(); e != nil && err == nil {
+			err = e
+		}
+		if err != nil {
+			_ = os.Remove(p)
+		}
+	}()
+	if _, err := io.Copy(out, in); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// MakeDirFileFetcher creates a file fetcher that serves urls from the files in dir,
+// for installing without network access. Served files are copied into filePath
+func MakeDirFileFetcher(dir, filePath string) FileFetcher {
+	return dirFileFetcher{
+		dir:      dir,
+		filePath: filePath,
+	}
+}
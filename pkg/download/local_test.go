@@ -0,0 +1,85 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_localFileFetcher_FetchFile_Tar(t *testing.T) {
+	basePath := t.TempDir()
+	contents, err := ioutil.ReadFile("../../test/testdata/hello.tar")
+	if err != nil {
+		t.Fatalf("could not read tar file")
+		return
+	}
+	tarFilePath := filepath.Join(basePath, "hello.tar")
+	if err := ioutil.WriteFile(tarFilePath, contents, os.ModePerm); err != nil {
+		t.Fatalf("could not copy tar file")
+		return
+	}
+	tarff := &tarFileFetcher{
+		out:         os.Stdout,
+		FileFetcher: MakeLocalFileFetcher(),
+	}
+	expandedFilePath, err := tarff.FetchFile(context.Background(), tarFilePath)
+	if err != nil {
+		t.Errorf("expected no error, got: %s", err)
+	}
+	expandedContents, err := ioutil.ReadFile(
+		filepath.Join(expandedFilePath, "hello.txt"),
+	)
+	if err != nil {
+		t.Fatalf("could not read file contents at %s", expandedFilePath)
+	}
+	if "hello" != string(expandedContents) {
+		t.Errorf(
+			`expected contents to be "hello", got: %s`,
+			string(expandedContents),
+		)
+	}
+}
+
+func Test_localFileFetcher_FetchFile_Missing(t *testing.T) {
+	l := MakeLocalFileFetcher()
+	if _, err := l.FetchFile(context.Background(), filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("expected err, got nil")
+	}
+	if _, err := l.FetchFile(context.Background(), t.TempDir()); err == nil {
+		t.Errorf("expected err for dir, got nil")
+	}
+}
+
+func Test_dirFileFetcher_FetchFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(
+		filepath.Join(dir, "hello.tar.gz"), []byte("hello"), os.ModePerm,
+	); err != nil {
+		t.Fatalf("could not write file: %s", err)
+	}
+	d := MakeDirFileFetcher(dir, t.TempDir())
+
+	p, err := d.FetchFile(context.Background(), "https://some.url/v1/hello.tar.gz?raw=true")
+	if err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if filepath.Dir(p) == dir {
+		t.Errorf("expected file to be copied out of %s", dir)
+	}
+	contents, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatalf("could not read file contents at %s", p)
+	}
+	if string(contents) != "hello" {
+		t.Errorf(`expected contents to be "hello", got: %s`, contents)
+	}
+
+	if _, err := d.FetchFile(
+		context.Background(), "https://some.url/v1/missing.tar.gz",
+	); !os.IsNotExist(errors.Unwrap(err)) {
+		t.Errorf("expected not exist err, got %v", err)
+	}
+}
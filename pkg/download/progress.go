@@ -0,0 +1,170 @@
+package download
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ProgressReporter is notified about the progress of downloads and extractions
+type ProgressReporter interface {
+	// Start begins reporting an operation, like "downloading", on the file name.
+	// total is the number of bytes the operation is expected to process, or -1 if unknown.
+	// Processed bytes are written to the returned writer, which is closed when the operation ends
+	Start(operation, name string, total int64) io.WriteCloser
+}
+
+// startProgress starts reporting on r, tolerating a nil reporter
+func startProgress(r ProgressReporter, operation, name string, total int64) io.WriteCloser {
+	if r == nil {
+		return nopProgress{}
+	}
+	return r.Start(operation, name, total)
+}
+
+type nopProgress struct{}
+
+func (nopProgress) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (nopProgress) Close() error {
+	return nil
+}
+
+const (
+	// barWidth is the number of characters used to draw a progress bar
+	barWidth = 30
+	// redrawInterval limits how often a progress bar is redrawn on a terminal
+	redrawInterval = 100 * time.Millisecond
+	// lineInterval is how often a line is printed when the output is not a terminal
+	lineInterval = 5 * time.Second
+)
+
+// TerminalReporter renders progress to a file, usually stdout. If the file is a terminal,
+// a progress bar is redrawn in place, otherwise progress is printed as periodic lines of plain text
+type TerminalReporter struct {
+	// Quiet disables all progress output
+	Quiet bool
+	out   io.Writer
+	tty   bool
+}
+
+func (t *TerminalReporter) Start(operation, name string, total int64) io.WriteCloser {
+	if t.Quiet || t.out == nil {
+		return nopProgress{}
+	}
+	return &progress{
+		out:       t.out,
+		tty:       t.tty,
+		operation: operation,
+		name:      name,
+		total:     total,
+		started:   time.Now(),
+	}
+}
+
+// progress tracks a single operation started on a TerminalReporter
+type progress struct {
+	out             io.Writer
+	tty             bool
+	operation, name string
+	total, current  int64
+	started, drawn  time.Time
+}
+
+func (p *progress) Write(b []byte) (int, error) {
+	p.current += int64(len(b))
+	now := time.Now()
+	if p.tty && now.Sub(p.drawn) >= redrawInterval {
+		p.drawn = now
+		_, _ = fmt.Fprintf(p.out, "\r%s", p.bar())
+	} else if !p.tty && now.Sub(p.drawn) >= lineInterval && now.Sub(p.started) >= lineInterval {
+		p.drawn = now
+		_, _ = fmt.Fprintln(p.out, p.line())
+	}
+	return len(b), nil
+}
+
+func (p *progress) Close() error {
+	if p.tty {
+		_, _ = fmt.Fprintf(p.out, "\r%s\n", p.bar())
+		return nil
+	}
+	_, _ = fmt.Fprintf(
+		p.out, "%s %s: done, %s in %s\n", p.operation, p.name, FormatSize(p.current),
+		time.Since(p.started).Round(time.Millisecond),
+	)
+	return nil
+}
+
+// bar draws the progress as a bar, when the total is known
+func (p *progress) bar() string {
+	if p.total <= 0 {
+		return fmt.Sprintf("%s %s %s", p.operation, p.name, FormatSize(p.current))
+	}
+	filled := int(float64(barWidth) * float64(p.current) / float64(p.total))
+	if filled > barWidth {
+		filled = barWidth
+	}
+	return fmt.Sprintf(
+		"%s %s [%s%s] %3d%% %s/%s", p.operation, p.name,
+		strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled),
+		p.percent(), FormatSize(p.current), FormatSize(p.total),
+	)
+}
+
+// line describes the progress in plain text
+func (p *progress) line() string {
+	if p.total <= 0 {
+		return fmt.Sprintf("%s %s: %s", p.operation, p.name, FormatSize(p.current))
+	}
+	return fmt.Sprintf(
+		"%s %s: %s/%s (%d%%)", p.operation, p.name, FormatSize(p.current),
+		FormatSize(p.total), p.percent(),
+	)
+}
+
+func (p *progress) percent() int64 {
+	percent := p.current * 100 / p.total
+	if percent > 100 {
+		percent = 100
+	}
+	return percent
+}
+
+// FormatSize formats a number of bytes in a human readable way, like 12.3 MiB
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// isTerminal reports whether the file is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// MakeTerminalReporter creates a reporter that renders progress to out
+func MakeTerminalReporter(out *os.File) *TerminalReporter {
+	if out == nil {
+		return &TerminalReporter{}
+	}
+	return &TerminalReporter{
+		out: out,
+		tty: isTerminal(out),
+	}
+}
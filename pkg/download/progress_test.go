@@ -0,0 +1,152 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordingReporter records the operations started on it, and the bytes written to them
+type recordingReporter struct {
+	operations []*recordedOperation
+}
+
+type recordedOperation struct {
+	operation string
+	total     int64
+	written   int64
+	closed    bool
+}
+
+func (r *recordingReporter) Start(operation, _ string, total int64) io.WriteCloser {
+	o := &recordedOperation{operation: operation, total: total}
+	r.operations = append(r.operations, o)
+	return o
+}
+
+func (o *recordedOperation) Write(p []byte) (int, error) {
+	o.written += int64(len(p))
+	return len(p), nil
+}
+
+func (o *recordedOperation) Close() error {
+	o.closed = true
+	return nil
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{n: 0, want: "0 B"},
+		{n: 1023, want: "1023 B"},
+		{n: 1024, want: "1.0 KiB"},
+		{n: 60 * 1024 * 1024, want: "60.0 MiB"},
+		{n: 3 * 1024 * 1024 * 1024 / 2, want: "1.5 GiB"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FormatSize(tt.n); got != tt.want {
+				t.Errorf("FormatSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminalReporter_Start(t *testing.T) {
+	tests := []struct {
+		name  string
+		tty   bool
+		quiet bool
+		want  string
+	}{
+		{
+			name: "terminal",
+			tty:  true,
+			want: "\rdownloading a.tar.gz [" + strings.Repeat("=", barWidth) + "] 100% 2.0 KiB/2.0 KiB\n",
+		},
+		{
+			name: "plain text",
+			want: "downloading a.tar.gz: done, 2.0 KiB in",
+		},
+		{
+			name:  "quiet",
+			tty:   true,
+			quiet: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			r := &TerminalReporter{Quiet: tt.quiet, out: &out, tty: tt.tty}
+			p := r.Start("downloading", "a.tar.gz", 2048)
+			// the bar is redrawn at most every redrawInterval, so the first write is drawn
+			// and the second one is only visible after closing
+			_, _ = p.Write(make([]byte, 1024))
+			out.Reset()
+			_, _ = p.Write(make([]byte, 1024))
+			_ = p.Close()
+			if !strings.HasPrefix(out.String(), tt.want) || (tt.want == "") != (out.Len() == 0) {
+				t.Errorf("Start() printed %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_basicFileFetcher_FetchFile_Progress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	r := &recordingReporter{}
+	f, err := MakeBasicFileFetcher(t.TempDir(), server.Client(), r)
+	if err != nil {
+		t.Fatalf("could not create file fetcher: %s", err)
+	}
+	if _, err := f.FetchFile(context.Background(), server.URL+"/hello.txt"); err != nil {
+		t.Fatalf("encountered error when fetching file: %s", err)
+	}
+	if len(r.operations) != 1 {
+		t.Fatalf("expected 1 reported operation, got %d", len(r.operations))
+	}
+	if o := r.operations[0]; o.total != 5 || o.written != 5 || !o.closed {
+		t.Errorf("expected 5 of 5 bytes to be reported, got %+v", *o)
+	}
+}
+
+func Test_zipFileFetcher_FetchFile_Progress(t *testing.T) {
+	contents, err := ioutil.ReadFile("../../test/testdata/hello.zip")
+	if err != nil {
+		t.Fatalf("could not read zip file")
+	}
+	zipFilePath := filepath.Join(t.TempDir(), "hello.zip")
+	if err := ioutil.WriteFile(zipFilePath, contents, os.ModePerm); err != nil {
+		t.Fatalf("could not copy zip file")
+	}
+
+	r := &recordingReporter{}
+	zipff, err := MakeZipFileFetcher(
+		os.Stdout, r, &testZipFileFetcher{zipFilePath: zipFilePath},
+	)
+	if err != nil {
+		t.Fatalf("could not create file fetcher: %s", err)
+	}
+	if _, err := zipff.FetchFile(context.Background(), "http://some.url"); err != nil {
+		t.Fatalf("expected no error, got: %s", err)
+	}
+	if len(r.operations) != 1 {
+		t.Fatalf("expected 1 reported operation, got %d", len(r.operations))
+	}
+	if o := r.operations[0]; o.total == 0 || o.written != o.total || !o.closed {
+		t.Errorf("expected all bytes to be reported, got %+v", *o)
+	}
+}
@@ -1,11 +1,14 @@
 package download
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
 )
 
 type retryFetcher struct {
@@ -16,20 +19,28 @@ type retryFetcher struct {
 	FileFetcher
 }
 
-func (r *retryFetcher) FetchFile(u string) (string, error) {
-	s, err := r.FileFetcher.FetchFile(u)
+func (r *retryFetcher) FetchFile(ctx context.Context, u string) (string, error) {
+	s, err := r.FileFetcher.FetchFile(ctx, u)
 	var count uint = 1
-	var urlErr *url.Error
-	for err != nil && errors.As(err, &urlErr) && count <= r.retryCount {
+	// a cancelled fetch also fails with a url error, but should not be retried
+	for err != nil && retryable(err) && ctx.Err() == nil && count <= r.retryCount {
 		if e := r.print(fmt.Sprintf("fetching file from url %s failed, retrying count: %d\n", u, count)); e != nil {
 			return s, e
 		}
-		s, err = r.FileFetcher.FetchFile(u)
+		s, err = r.FileFetcher.FetchFile(ctx, u)
 		count++
 	}
 	return s, err
 }
 
+// retryable checks if a fetch failed in a way that may succeed when retried, like a
+// connection error, or a download that was cut off
+func retryable(err error) bool {
+	var urlErr *url.Error
+	var incompleteErr *kpkgerr.IncompleteDownloadErr
+	return errors.As(err, &urlErr) || errors.As(err, &incompleteErr)
+}
+
 func (r *retryFetcher) print(message string) error {
 	if r.out != nil {
 		if _, err := fmt.Fprint(r.out, message); err != nil {
@@ -1,6 +1,7 @@
 package download
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -21,7 +22,7 @@ func (t timeoutErr) Timeout() bool {
 	return true
 }
 
-func (t *testUrlErrFetcher) FetchFile(_ string) (string, error) {
+func (t *testUrlErrFetcher) FetchFile(_ context.Context, _ string) (string, error) {
 	return "", &url.Error{
 		Op:  "",
 		URL: "",
@@ -35,7 +36,7 @@ func Test_retryFetcher_FetchFile_ZeroRetries(t *testing.T) {
 		out:         os.Stdout,
 		FileFetcher: &testUrlErrFetcher{},
 	}
-	u, err := r.FetchFile("https://some.url")
+	u, err := r.FetchFile(context.Background(), "https://some.url")
 	if err == nil {
 		t.Errorf("expected err, got nil")
 	}
@@ -50,7 +51,7 @@ func Test_retryFetcher_FetchFile_OneRetries(t *testing.T) {
 		out:         os.Stdout,
 		FileFetcher: &testUrlErrFetcher{},
 	}
-	u, err := r.FetchFile("https://some.url")
+	u, err := r.FetchFile(context.Background(), "https://some.url")
 	if err == nil {
 		t.Errorf("expected err, got nil")
 	}
@@ -65,7 +66,7 @@ func Test_retryFetcher_FetchFile_TwoRetries(t *testing.T) {
 		out:         os.Stdout,
 		FileFetcher: &testUrlErrFetcher{},
 	}
-	u, err := r.FetchFile("https://some.url")
+	u, err := r.FetchFile(context.Background(), "https://some.url")
 	if err == nil {
 		t.Errorf("expected err, got nil")
 	}
@@ -80,7 +81,7 @@ func Test_retryFetcher_FetchFile_ThreeRetries(t *testing.T) {
 		out:         os.Stdout,
 		FileFetcher: &testUrlErrFetcher{},
 	}
-	u, err := r.FetchFile("https://some.url")
+	u, err := r.FetchFile(context.Background(), "https://some.url")
 	if err == nil {
 		t.Errorf("expected err, got nil")
 	}
@@ -93,7 +94,7 @@ type testErrFetcher struct {
 	path string
 }
 
-func (t testErrFetcher) FetchFile(url string) (string, error) {
+func (t testErrFetcher) FetchFile(_ context.Context, url string) (string, error) {
 	c := &http.Client{
 		Timeout: time.Second,
 	}
@@ -123,7 +124,7 @@ func Test_retryFetcher_FetchFile_Retry_Success(t *testing.T) {
 			"some/path",
 		},
 	}
-	u, err := r.FetchFile(server.URL)
+	u, err := r.FetchFile(context.Background(), server.URL)
 	if err != nil {
 		t.Errorf("expected nil err, got %s", err)
 	}
@@ -0,0 +1,110 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RewriteRuleSep separates the url to match from its replacement in a rewrite rule
+const RewriteRuleSep = "=>"
+
+// RewriteRule rewrites urls that match it, for example to download artifacts from a mirror
+type RewriteRule struct {
+	// From is the prefix of the urls to rewrite. If Regexp is set, From is a regular expression instead
+	From string
+	// To replaces the prefix, or the matches of the regular expression, which may refer to its
+	// submatches like $1
+	To     string
+	Regexp bool
+	re     *regexp.Regexp
+}
+
+// rewrite returns the rewritten url, and whether the rule matched it
+func (r RewriteRule) rewrite(u string) (string, bool) {
+	if r.re != nil {
+		if !r.re.MatchString(u) {
+			return u, false
+		}
+		return r.re.ReplaceAllString(u, r.To), true
+	}
+	if !strings.HasPrefix(u, r.From) {
+		return u, false
+	}
+	return r.To + strings.TrimPrefix(u, r.From), true
+}
+
+// ParseRewriteRule parses a rule written as from=>to. If regex is true, from is a regular expression
+func ParseRewriteRule(s string, regex bool) (RewriteRule, error) {
+	parts := strings.SplitN(s, RewriteRuleSep, 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return RewriteRule{}, fmt.Errorf(
+			"invalid rewrite rule %q, expected from%sto", s, RewriteRuleSep,
+		)
+	}
+	r := RewriteRule{From: parts[0], To: parts[1], Regexp: regex}
+	if regex {
+		re, err := regexp.Compile(r.From)
+		if err != nil {
+			return RewriteRule{}, fmt.Errorf("invalid rewrite rule %q: %w", s, err)
+		}
+		r.re = re
+	}
+	return r, nil
+}
+
+// Rewriter rewrites urls with the first of its rules that matches them
+type Rewriter struct {
+	rules []RewriteRule
+}
+
+// Rewrite returns the url rewritten by the first rule that matches it, or the url itself if
+// no rule matches. A nil Rewriter returns the url as is
+func (r *Rewriter) Rewrite(u string) string {
+	if r == nil {
+		return u
+	}
+	for _, rule := range r.rules {
+		if rewritten, ok := rule.rewrite(u); ok {
+			return rewritten
+		}
+	}
+	return u
+}
+
+// MakeRewriter returns a Rewriter that tries the rules in order
+func MakeRewriter(rules ...RewriteRule) (*Rewriter, error) {
+	rules = append([]RewriteRule{}, rules...)
+	for i, rule := range rules {
+		if !rule.Regexp || rule.re != nil {
+			continue
+		}
+		re, err := regexp.Compile(rule.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rewrite rule %q: %w", rule.From, err)
+		}
+		rules[i].re = re
+	}
+	return &Rewriter{rules: rules}, nil
+}
+
+// rewriteFileFetcher fetches files from rewritten urls
+type rewriteFileFetcher struct {
+	rewriter *Rewriter
+	FileFetcher
+}
+
+func (r *rewriteFileFetcher) FetchFile(ctx context.Context, u string) (string, error) {
+	return r.FileFetcher.FetchFile(ctx, r.rewriter.Rewrite(u))
+}
+
+func MakeRewriteFileFetcher(rewriter *Rewriter, f FileFetcher) (FileFetcher, error) {
+	if f == nil {
+		return nil, fmt.Errorf("file fetcher param cannot be nil")
+	}
+	return &rewriteFileFetcher{
+		rewriter:    rewriter,
+		FileFetcher: f,
+	}, nil
+}
@@ -0,0 +1,130 @@
+package download
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRewriter_Rewrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   []string
+		regexps []string
+		u       string
+		want    string
+	}{
+		{
+			name:  "prefix",
+			rules: []string{"https://github.com/=>https://mirror.url/github/"},
+			u:     "https://github.com/a/b/releases/download/v1.0.0/b.tar.gz",
+			want:  "https://mirror.url/github/a/b/releases/download/v1.0.0/b.tar.gz",
+		},
+		{
+			name:  "no match",
+			rules: []string{"https://github.com/=>https://mirror.url/github/"},
+			u:     "https://dl.k8s.io/release/v1.20.0/bin/linux/amd64/kubectl",
+			want:  "https://dl.k8s.io/release/v1.20.0/bin/linux/amd64/kubectl",
+		},
+		{
+			name: "first matching rule",
+			rules: []string{
+				"https://github.com/a/=>https://mirror.url/a/",
+				"https://github.com/=>https://mirror.url/github/",
+			},
+			u:    "https://github.com/a/b",
+			want: "https://mirror.url/a/b",
+		},
+		{
+			name:    "regexp",
+			regexps: []string{`^https://dl\.k8s\.io/release/(v[^/]+)/=>https://mirror.url/k8s/$1/`},
+			u:       "https://dl.k8s.io/release/v1.20.0/bin/linux/amd64/kubectl",
+			want:    "https://mirror.url/k8s/v1.20.0/bin/linux/amd64/kubectl",
+		},
+		{
+			name:    "prefix before regexp",
+			rules:   []string{"https://github.com/=>https://mirror.url/github/"},
+			regexps: []string{`github\.com=>other.url`},
+			u:       "https://github.com/a/b",
+			want:    "https://mirror.url/github/a/b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules []RewriteRule
+			for _, s := range tt.rules {
+				r, err := ParseRewriteRule(s, false)
+				if err != nil {
+					t.Fatalf("ParseRewriteRule() error = %v, expected nil", err)
+				}
+				rules = append(rules, r)
+			}
+			for _, s := range tt.regexps {
+				r, err := ParseRewriteRule(s, true)
+				if err != nil {
+					t.Fatalf("ParseRewriteRule() error = %v, expected nil", err)
+				}
+				rules = append(rules, r)
+			}
+			r, err := MakeRewriter(rules...)
+			if err != nil {
+				t.Fatalf("MakeRewriter() error = %v, expected nil", err)
+			}
+			if got := r.Rewrite(tt.u); got != tt.want {
+				t.Errorf("Rewrite() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRewriteRule(t *testing.T) {
+	tests := []struct {
+		s       string
+		regex   bool
+		wantErr bool
+	}{
+		{s: "https://a.url/=>https://b.url/"},
+		{s: "https://a.url/=>"},
+		{s: "https://a.url/", wantErr: true},
+		{s: "=>https://b.url/", wantErr: true},
+		{s: "(=>https://b.url/", regex: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if _, err := ParseRewriteRule(tt.s, tt.regex); (err != nil) != tt.wantErr {
+				t.Errorf("ParseRewriteRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// urlRecordingFileFetcher records the urls it is asked to fetch
+type urlRecordingFileFetcher struct {
+	urls []string
+}
+
+func (u *urlRecordingFileFetcher) FetchFile(_ context.Context, url string) (string, error) {
+	u.urls = append(u.urls, url)
+	return "", nil
+}
+
+func Test_rewriteFileFetcher_FetchFile(t *testing.T) {
+	rule, err := ParseRewriteRule("https://a.url/=>https://b.url/", false)
+	if err != nil {
+		t.Fatalf("ParseRewriteRule() error = %v, expected nil", err)
+	}
+	r, err := MakeRewriter(rule)
+	if err != nil {
+		t.Fatalf("MakeRewriter() error = %v, expected nil", err)
+	}
+	inner := &urlRecordingFileFetcher{}
+	f, err := MakeRewriteFileFetcher(r, inner)
+	if err != nil {
+		t.Fatalf("MakeRewriteFileFetcher() error = %v, expected nil", err)
+	}
+	if _, err := f.FetchFile(context.Background(), "https://a.url/file"); err != nil {
+		t.Fatalf("FetchFile() error = %v, expected nil", err)
+	}
+	if len(inner.urls) != 1 || inner.urls[0] != "https://b.url/file" {
+		t.Errorf("fetched %v, expected the rewritten url", inner.urls)
+	}
+}
@@ -2,33 +2,32 @@ package download
 
 import (
 	"archive/tar"
+	"context"
 	"fmt"
 	"io"
-	"math/rand"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
 type tarFileFetcher struct {
 	// print logs to file, if not nil
 	out io.Writer
+	// progress of extractions is reported here, if not nil
+	progress ProgressReporter
 	FileFetcher
 }
 
-func (r *tarFileFetcher) FetchFile(u string) (string, error) {
-	s, err := r.FileFetcher.FetchFile(u)
+func (r *tarFileFetcher) FetchFile(ctx context.Context, u string) (string, error) {
+	s, err := r.FileFetcher.FetchFile(ctx, u)
 	if err != nil || filepath.Ext(s) != ".tar" {
 		return s, err
 	}
 
 	// create a new folder to store the contents of the tar file in
-	now := time.Now()
-	randPath := now.Unix() * int64(rand.Uint32())
-	p := filepath.Join(filepath.Dir(s), fmt.Sprint(randPath))
-
-	if err := os.MkdirAll(p, 0755); err != nil {
+	p, err := ioutil.TempDir(filepath.Dir(s), "")
+	if err != nil {
 		return s, err
 	}
 
@@ -39,9 +38,18 @@ func (r *tarFileFetcher) FetchFile(u string) (string, error) {
 		return s, err
 	}
 	defer f.Close()
-	tarReader := tar.NewReader(f)
+	info, err := f.Stat()
+	if err != nil {
+		return s, err
+	}
+	progress := startProgress(r.progress, "expanding", filepath.Base(s), info.Size())
+	defer progress.Close()
+	tarReader := tar.NewReader(io.TeeReader(f, progress))
 
 	for {
+		if err := ctx.Err(); err != nil {
+			return p, err
+		}
 		header, err := tarReader.Next()
 
 		if err == io.EOF {
@@ -107,12 +115,15 @@ func (r *tarFileFetcher) FetchFile(u string) (string, error) {
 	return p, nil
 }
 
-func MakeTarFileFetcher(out *os.File, f FileFetcher) (FileFetcher, error) {
+func MakeTarFileFetcher(
+	out *os.File, progress ProgressReporter, f FileFetcher,
+) (FileFetcher, error) {
 	if f == nil {
 		return nil, fmt.Errorf("file fetcher param cannot be nil")
 	}
 	return &tarFileFetcher{
 		out:         out,
+		progress:    progress,
 		FileFetcher: f,
 	}, nil
 }
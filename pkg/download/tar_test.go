@@ -1,6 +1,7 @@
 package download
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -11,7 +12,7 @@ type testTarFileFetcher struct {
 	zipFilePath string
 }
 
-func (t testTarFileFetcher) FetchFile(_ string) (string, error) {
+func (t testTarFileFetcher) FetchFile(_ context.Context, _ string) (string, error) {
 	return filepath.Abs(t.zipFilePath)
 }
 
@@ -31,7 +32,7 @@ func Test_tarFileFetcher_FetchFile_Zip(t *testing.T) {
 		out:         os.Stdout,
 		FileFetcher: &testTarFileFetcher{zipFilePath: tarFilePath},
 	}
-	expandedFilePath, err := tarff.FetchFile("http://some.url")
+	expandedFilePath, err := tarff.FetchFile(context.Background(), "http://some.url")
 	if err != nil {
 		t.Errorf("expected no error, got: %s", err)
 	}
@@ -73,7 +74,7 @@ func Test_tarFileFetcher_FetchFile(t *testing.T) {
 		out:         os.Stdout,
 		FileFetcher: &testTarFileFetcher{zipFilePath: normFilePath},
 	}
-	filePath, err := tarff.FetchFile("http://some.url")
+	filePath, err := tarff.FetchFile(context.Background(), "http://some.url")
 	if err != nil {
 		t.Errorf("expected no error, got: %s", err)
 	}
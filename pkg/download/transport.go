@@ -0,0 +1,270 @@
+package download
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TransportOptions configures how requests are sent. Instead of one deadline for a whole request,
+// which kills large downloads on slow links, each phase of a request has its own timeout
+type TransportOptions struct {
+	// ConnectTimeout bounds how long establishing a connection may take
+	ConnectTimeout time.Duration
+	// TLSHandshakeTimeout bounds how long the TLS handshake may take
+	TLSHandshakeTimeout time.Duration
+	// ReadTimeout bounds how long to wait for the response, and then for each read of the body.
+	// A download is only cut off if it stalls, not because it takes long
+	ReadTimeout time.Duration
+	// CABundle is the path of a PEM file of CA certificates that are trusted on top of
+	// the certificates of the system, for example of a TLS intercepting proxy
+	CABundle string
+	// Proxy is the url of the proxy to send requests through. If empty, the proxy is taken from
+	// the HTTPS_PROXY, HTTP_PROXY and NO_PROXY env vars. Either way, the hosts in NO_PROXY
+	// are not sent through the proxy
+	Proxy string
+	// InsecureSkipVerify accepts any certificate presented by the server. It defeats TLS,
+	// so it is never enabled by default
+	InsecureSkipVerify bool
+}
+
+// DefaultTransportOptions returns the timeouts used unless configured otherwise
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		ConnectTimeout:      30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		ReadTimeout:         30 * time.Second,
+	}
+}
+
+// Transport sends requests with the configured TransportOptions. It can be configured again
+// once flags are parsed, after it was handed to the file fetchers and clients that share it
+type Transport struct {
+	mu          sync.RWMutex
+	transport   *http.Transport
+	readTimeout time.Duration
+}
+
+// Configure replaces how requests are sent. Requests that are in flight are not affected
+func (t *Transport) Configure(opts TransportOptions) error {
+	transport, err := makeHttpTransport(opts)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	old := t.transport
+	t.transport, t.readTimeout = transport, opts.ReadTimeout
+	t.mu.Unlock()
+	if old != nil {
+		old.CloseIdleConnections()
+	}
+	return nil
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.RLock()
+	transport, readTimeout := t.transport, t.readTimeout
+	t.mu.RUnlock()
+	if readTimeout <= 0 {
+		return transport.RoundTrip(req)
+	}
+
+	// the request is cancelled once reading the body stalls for longer than the read timeout
+	ctx, cancel := context.WithCancel(req.Context())
+	resp, err := transport.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = makeIdleTimeoutBody(resp.Body, readTimeout, cancel)
+	return resp, nil
+}
+
+// idleTimeoutBody cancels the request if no data is read for longer than timeout
+type idleTimeoutBody struct {
+	body    io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	expired bool
+}
+
+func makeIdleTimeoutBody(
+	body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc,
+) *idleTimeoutBody {
+	b := &idleTimeoutBody{body: body, timeout: timeout, cancel: cancel}
+	b.timer = time.AfterFunc(timeout, b.expire)
+	return b
+}
+
+func (b *idleTimeoutBody) expire() {
+	b.mu.Lock()
+	b.expired = true
+	b.mu.Unlock()
+	b.cancel()
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.expired {
+		if err != nil {
+			err = fmt.Errorf("no data received for %s: %w", b.timeout, err)
+		}
+		return n, err
+	}
+	b.timer.Reset(b.timeout)
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	defer b.cancel()
+	return b.body.Close()
+}
+
+func makeHttpTransport(opts TransportOptions) (*http.Transport, error) {
+	proxy := http.ProxyFromEnvironment
+	if opts.Proxy != "" {
+		proxyUrl, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url %s: %w", opts.Proxy, err)
+		}
+		proxy = proxyExcept(proxyUrl, getenv("NO_PROXY", "no_proxy"))
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CABundle != "" {
+		pool, err := loadCABundle(opts.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   opts.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	return &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: opts.ReadTimeout,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}, nil
+}
+
+// proxyExcept returns a proxy func that sends requests through proxyUrl, except for the hosts
+// matched by noProxy, which is a list in the format of the NO_PROXY env var
+func proxyExcept(proxyUrl *url.URL, noProxy string) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if excluded(req.URL, noProxy) {
+			return nil, nil
+		}
+		return proxyUrl, nil
+	}
+}
+
+// excluded checks if requests to u bypass the proxy, the same way that http.ProxyFromEnvironment
+// does. noProxy is a comma separated list of hosts, domains, IPs and CIDR ranges, each optionally
+// with a port. A domain matches its subdomains, or only its subdomains if it starts with a dot,
+// and * matches every host. Requests to localhost never go through the proxy
+func excluded(u *url.URL, noProxy string) bool {
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	ip := net.ParseIP(host)
+	if host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return true
+	}
+	for _, entry := range strings.Split(strings.ToLower(noProxy), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if entryIp := net.ParseIP(entryHost); entryIp != nil {
+			if ip != nil && entryIp.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		entryHost = strings.TrimPrefix(entryHost, "*")
+		if strings.HasPrefix(entryHost, ".") {
+			if strings.HasSuffix(host, entryHost) {
+				return true
+			}
+		} else if host == entryHost || strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+	return false
+}
+
+// getenv returns the value of the first env var that is set
+func getenv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// loadCABundle returns the certificates of the system, along with the certificates in the PEM file
+func loadCABundle(p string) (*x509.CertPool, error) {
+	pem, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", p)
+	}
+	return pool, nil
+}
+
+// MakeTransport returns a Transport that sends requests with opts
+func MakeTransport(opts TransportOptions) (*Transport, error) {
+	t := &Transport{}
+	if err := t.Configure(opts); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
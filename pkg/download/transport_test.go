@@ -0,0 +1,200 @@
+package download
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransport_ReadTimeout(t *testing.T) {
+	tests := []struct {
+		name string
+		// the body is sent in chunks, with delay between them
+		chunks  int
+		delay   time.Duration
+		wantErr bool
+	}{
+		{
+			name:   "slow download that keeps going",
+			chunks: 5,
+			delay:  40 * time.Millisecond,
+		},
+		{
+			name:    "stalled download",
+			chunks:  2,
+			delay:   500 * time.Millisecond,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(
+				http.HandlerFunc(
+					func(rw http.ResponseWriter, r *http.Request) {
+						for i := 0; i < tt.chunks; i++ {
+							if i > 0 {
+								select {
+								case <-time.After(tt.delay):
+								case <-r.Context().Done():
+									return
+								}
+							}
+							_, _ = rw.Write([]byte("chunk"))
+							rw.(http.Flusher).Flush()
+						}
+					},
+				),
+			)
+			defer ts.Close()
+
+			opts := DefaultTransportOptions()
+			opts.ReadTimeout = 100 * time.Millisecond
+			transport, err := MakeTransport(opts)
+			if err != nil {
+				t.Fatalf("MakeTransport() error = %v, expected nil", err)
+			}
+			resp, err := (&http.Client{Transport: transport}).Get(ts.URL)
+			if err != nil {
+				t.Fatalf("Get() error = %v, expected nil", err)
+			}
+			defer resp.Body.Close()
+			b, err := ioutil.ReadAll(resp.Body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadAll() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "no data received") {
+				t.Errorf("ReadAll() error = %v, expected the read timeout to be reported", err)
+			}
+			if !tt.wantErr && string(b) != strings.Repeat("chunk", tt.chunks) {
+				t.Errorf("ReadAll() = %s, expected the whole body", b)
+			}
+		})
+	}
+}
+
+func TestTransport_TLS(t *testing.T) {
+	ts := httptest.NewTLSServer(
+		http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}),
+	)
+	defer ts.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(
+		bundle, pem.EncodeToMemory(
+			&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw},
+		), os.ModePerm,
+	); err != nil {
+		t.Fatalf("could not write CA bundle: %s", err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    TransportOptions
+		wantErr bool
+	}{
+		{
+			name:    "untrusted certificate",
+			wantErr: true,
+		},
+		{
+			name: "certificate in CA bundle",
+			opts: TransportOptions{CABundle: bundle},
+		},
+		{
+			name: "insecure",
+			opts: TransportOptions{InsecureSkipVerify: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, err := MakeTransport(tt.opts)
+			if err != nil {
+				t.Fatalf("MakeTransport() error = %v, expected nil", err)
+			}
+			resp, err := (&http.Client{Transport: transport}).Get(ts.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestTransport_Proxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(
+		http.HandlerFunc(
+			func(rw http.ResponseWriter, r *http.Request) {
+				proxied = r.URL.String()
+			},
+		),
+	)
+	defer proxy.Close()
+
+	transport, err := MakeTransport(TransportOptions{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatalf("MakeTransport() error = %v, expected nil", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get("http://some.url/file")
+	if err != nil {
+		t.Fatalf("Get() error = %v, expected nil", err)
+	}
+	resp.Body.Close()
+	if proxied != "http://some.url/file" {
+		t.Errorf("proxy received %q, expected the request to be sent through it", proxied)
+	}
+}
+
+func Test_excluded(t *testing.T) {
+	noProxy := "mirror.corp, .internal, 10.0.0.0/8, 192.168.1.1, artifacts.corp:8443"
+	tests := []struct {
+		url     string
+		noProxy string
+		want    bool
+	}{
+		{url: "https://github.com/a", noProxy: noProxy},
+		{url: "https://mirror.corp/a", noProxy: noProxy, want: true},
+		{url: "https://eu.mirror.corp/a", noProxy: noProxy, want: true},
+		{url: "https://notmirror.corp/a", noProxy: noProxy},
+		{url: "https://releases.internal/a", noProxy: noProxy, want: true},
+		{url: "https://internal/a", noProxy: noProxy},
+		{url: "http://10.1.2.3/a", noProxy: noProxy, want: true},
+		{url: "http://192.168.1.1:8080/a", noProxy: noProxy, want: true},
+		{url: "http://192.168.1.2/a", noProxy: noProxy},
+		{url: "https://artifacts.corp:8443/a", noProxy: noProxy, want: true},
+		{url: "https://artifacts.corp/a", noProxy: noProxy},
+		{url: "http://localhost:8080/a", want: true},
+		{url: "http://127.0.0.1/a", want: true},
+		{url: "https://github.com/a", noProxy: "*", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := excluded(u, tt.noProxy); got != tt.want {
+				t.Errorf("excluded(%s, %q) = %v, want %v", tt.url, tt.noProxy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakeTransport_InvalidCABundle(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	if err := ioutil.WriteFile(bundle, []byte("not a certificate"), os.ModePerm); err != nil {
+		t.Fatalf("could not write CA bundle: %s", err)
+	}
+	if _, err := MakeTransport(TransportOptions{CABundle: bundle}); err == nil {
+		t.Errorf("MakeTransport() error = nil, expected an invalid CA bundle to be reported")
+	}
+}
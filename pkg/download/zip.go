@@ -2,23 +2,25 @@ package download
 
 import (
 	"archive/zip"
+	"context"
 	"fmt"
 	"io"
-	"math/rand"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 )
 
 type zipFileFetcher struct {
 	// print logs to file, if not nil
 	out io.Writer
+	// progress of extractions is reported here, if not nil
+	progress ProgressReporter
 	FileFetcher
 }
 
-func (r *zipFileFetcher) FetchFile(u string) (string, error) {
-	s, err := r.FileFetcher.FetchFile(u)
+func (r *zipFileFetcher) FetchFile(ctx context.Context, u string) (string, error) {
+	s, err := r.FileFetcher.FetchFile(ctx, u)
 	if err != nil {
 		return s, err
 	}
@@ -29,9 +31,10 @@ func (r *zipFileFetcher) FetchFile(u string) (string, error) {
 	print(fmt.Sprintf("unzipping .zip file %s\n", s))
 
 	// create a new folder to store the contents of the zip file in
-	now := time.Now()
-	randPath := now.Unix() * int64(rand.Uint32())
-	p := filepath.Join(filepath.Dir(s), fmt.Sprint(randPath))
+	p, err := ioutil.TempDir(filepath.Dir(s), "")
+	if err != nil {
+		return s, err
+	}
 
 	zipReader, err := zip.OpenReader(s)
 	if err != nil {
@@ -41,7 +44,17 @@ func (r *zipFileFetcher) FetchFile(u string) (string, error) {
 		_ = zipReader.Close()
 	}()
 
+	var total int64
+	for _, f := range zipReader.File {
+		total += int64(f.UncompressedSize64)
+	}
+	progress := startProgress(r.progress, "unzipping", filepath.Base(s), total)
+	defer progress.Close()
+
 	for _, f := range zipReader.File {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
 		// Store filename/path for returning and using later on
 		fpath := filepath.Join(p, f.Name)
 
@@ -73,7 +86,7 @@ func (r *zipFileFetcher) FetchFile(u string) (string, error) {
 			return "", err
 		}
 
-		_, err = io.Copy(outFile, rc)
+		_, err = io.Copy(io.MultiWriter(outFile, progress), rc)
 
 		// Close the file without defer to close before next iteration of loop
 		if err := outFile.Close(); err != nil {
@@ -92,12 +105,15 @@ func (r *zipFileFetcher) FetchFile(u string) (string, error) {
 	return p, nil
 }
 
-func MakeZipFileFetcher(out *os.File, f FileFetcher) (FileFetcher, error) {
+func MakeZipFileFetcher(
+	out *os.File, progress ProgressReporter, f FileFetcher,
+) (FileFetcher, error) {
 	if f == nil {
 		return nil, fmt.Errorf("file fetcher param cannot be nil")
 	}
 	return &zipFileFetcher{
 		out:         out,
+		progress:    progress,
 		FileFetcher: f,
 	}, nil
 }
@@ -1,6 +1,7 @@
 package download
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -11,7 +12,7 @@ type testZipFileFetcher struct {
 	zipFilePath string
 }
 
-func (t testZipFileFetcher) FetchFile(_ string) (string, error) {
+func (t testZipFileFetcher) FetchFile(_ context.Context, _ string) (string, error) {
 	return filepath.Abs(t.zipFilePath)
 }
 
@@ -31,7 +32,7 @@ func Test_zipFileFetcher_FetchFile_Zip(t *testing.T) {
 		out:         os.Stdout,
 		FileFetcher: &testZipFileFetcher{zipFilePath: zipFilePath},
 	}
-	unzippedFilePath, err := zipff.FetchFile("http://some.url")
+	unzippedFilePath, err := zipff.FetchFile(context.Background(), "http://some.url")
 	if err != nil {
 		t.Errorf("expected no error, got: %s", err)
 	}
@@ -77,7 +78,7 @@ func Test_zipFileFetcher_FetchFile(t *testing.T) {
 		out:         os.Stdout,
 		FileFetcher: &testZipFileFetcher{zipFilePath: normFilePath},
 	}
-	unzippedFilePath, err := zipff.FetchFile("http://some.url")
+	unzippedFilePath, err := zipff.FetchFile(context.Background(), "http://some.url")
 	if err != nil {
 		t.Errorf("expected no error, got: %s", err)
 	}
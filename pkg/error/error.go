@@ -3,6 +3,7 @@ package error
 import (
 	"fmt"
 	"runtime"
+	"time"
 )
 
 type InstalledErr struct {
@@ -21,3 +22,130 @@ type UnsupportedRuntimeErr struct {
 func (u *UnsupportedRuntimeErr) Error() string {
 	return fmt.Sprintf("downloading binary %s is not support with runtime %s/%s", u.Binary, runtime.GOOS, runtime.GOARCH)
 }
+
+type ChecksumErr struct {
+	Artifact,
+	Expected,
+	Actual string
+}
+
+func (c *ChecksumErr) Error() string {
+	return fmt.Sprintf(
+		"checksum mismatch for artifact %s: expected sha256 %s, got %s",
+		c.Artifact, c.Expected, c.Actual,
+	)
+}
+
+type SignatureErr struct {
+	Artifact string
+	Err      error
+}
+
+func (s *SignatureErr) Error() string {
+	return fmt.Sprintf("signature verification failed for %s: %s", s.Artifact, s.Err)
+}
+
+func (s *SignatureErr) Unwrap() error {
+	return s.Err
+}
+
+type HttpStatusErr struct {
+	Url        string
+	StatusCode int
+}
+
+func (h *HttpStatusErr) Error() string {
+	return fmt.Sprintf("incorrect status for downloading %s: %d", h.Url, h.StatusCode)
+}
+
+type LockTimeoutErr struct {
+	Path    string
+	Timeout time.Duration
+}
+
+func (l *LockTimeoutErr) Error() string {
+	return fmt.Sprintf(
+		"timed out after %s waiting for lock %s, another kpkg process may be running",
+		l.Timeout, l.Path,
+	)
+}
+
+type IncompleteDownloadErr struct {
+	Url string
+	// Expected is the number of bytes the server announced, or -1 if unknown
+	Expected,
+	Actual int64
+	Err error
+}
+
+func (i *IncompleteDownloadErr) Error() string {
+	msg := fmt.Sprintf("download of %s is incomplete, got %d bytes", i.Url, i.Actual)
+	if i.Expected >= 0 {
+		msg = fmt.Sprintf("%s of %d", msg, i.Expected)
+	}
+	if i.Err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, i.Err)
+	}
+	return msg
+}
+
+func (i *IncompleteDownloadErr) Unwrap() error {
+	return i.Err
+}
+
+type RateLimitErr struct {
+	// Reset is when the rate limit resets, or zero if unknown
+	Reset time.Time
+	// Authenticated is true if the requests were sent with a token
+	Authenticated bool
+	Err           error
+}
+
+func (r *RateLimitErr) Error() string {
+	msg := "GitHub API rate limit exceeded"
+	if !r.Reset.IsZero() {
+		msg = fmt.Sprintf(
+			"%s, the limit resets at %s (in %s)", msg, r.Reset.Format(time.RFC3339),
+			time.Until(r.Reset).Round(time.Second),
+		)
+	}
+	if !r.Authenticated {
+		msg += ". Set the GITHUB_TOKEN or GH_TOKEN env var to a GitHub token to raise the limit"
+	}
+	return msg
+}
+
+func (r *RateLimitErr) Unwrap() error {
+	return r.Err
+}
+
+// ExitErr is returned when kpkg should exit with a code without printing an error, like the exit
+// code of a binary that it ran
+type ExitErr struct {
+	Code int
+}
+
+func (e *ExitErr) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// NoChecksumErr is returned by a Checksummer for a version that was released without a checksum,
+// like the releases of a binary from before it published checksums
+type NoChecksumErr struct {
+	Binary, Version string
+}
+
+func (n *NoChecksumErr) Error() string {
+	return fmt.Sprintf("no checksum is published for %s %s", n.Binary, n.Version)
+}
+
+// UnverifiedErr is returned when an artifact must be verified before it is installed,
+// but there is nothing to verify it against
+type UnverifiedErr struct {
+	Artifact string
+	Reason   string
+}
+
+func (u *UnverifiedErr) Error() string {
+	return fmt.Sprintf("refusing to install unverified artifact %s: %s", u.Artifact, u.Reason)
+}
@@ -0,0 +1,178 @@
+package index
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spachava753/kpkg/pkg/tool"
+)
+
+// FormatVersion is the version of the format that entries are written in. It is bumped whenever
+// the format changes, so that entries written by older kpkg releases can be migrated
+const FormatVersion = 1
+
+// DefaultTTL is how long listed versions are used before they are listed again
+const DefaultTTL = 24 * time.Hour
+
+// Entry holds the versions of a binary, as they were when they were last listed
+type Entry struct {
+	FormatVersion int      `json:"formatVersion"`
+	Binary        string   `json:"binary"`
+	Versions      []string `json:"versions"`
+	// Max is the max number of versions that were asked for when listing the versions
+	Max       uint      `json:"max"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Index is a local index of the versions of binaries, so that installing or listing a binary
+// doesn't have to ask the source of the binary for its versions every time.
+// Each binary has its own entry in the dir of the index, so that updating the versions of
+// one binary doesn't race with updating another
+type Index struct {
+	dir string
+	// TTL is how long entries are used before the versions are listed again.
+	// If it is not positive, the versions are always listed again
+	TTL time.Duration
+	// print warnings to out, if not nil
+	out io.Writer
+}
+
+// Dir returns the dir that the entries of the index are written to
+func (i *Index) Dir() string {
+	return i.dir
+}
+
+// WithTTL returns a copy of the index that lists versions again once they are older than ttl
+func (i *Index) WithTTL(ttl time.Duration) *Index {
+	return &Index{dir: i.dir, TTL: ttl, out: i.out}
+}
+
+// Get returns the entry of the binary, or nil if the binary is not in the index.
+// Entries that can't be read, or that are in a format newer than this release understands,
+// are treated as missing, so that they are listed again and overwritten
+func (i *Index) Get(binary string) (*Entry, error) {
+	b, err := ioutil.ReadFile(i.entryPath(binary))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var e Entry
+	if err := json.Unmarshal(b, &e); err != nil || !migrate(&e) {
+		return nil, nil
+	}
+	return &e, nil
+}
+
+// Versions lists the versions of the binary from the index. If the entry of the binary is stale,
+// or holds fewer versions than asked for, the versions are listed again and the entry is updated.
+// If listing them fails, a stale entry is used instead, so that binaries can still be installed
+// while the source of the binary can't be reached
+func (i *Index) Versions(ctx context.Context, b tool.Binary, max uint) ([]string, error) {
+	e, err := i.Get(b.Name())
+	if err != nil {
+		return nil, err
+	}
+	if e != nil && i.fresh(e, max) {
+		return head(e.Versions, max), nil
+	}
+	versions, err := i.Refresh(ctx, b, max)
+	if err != nil && e != nil && max <= e.Max && ctx.Err() == nil {
+		i.print(fmt.Sprintf(
+			"warning: could not list versions of %s, using versions listed at %s: %s\n",
+			b.Name(), e.UpdatedAt.Format(time.RFC3339), err,
+		))
+		return head(e.Versions, max), nil
+	}
+	return versions, err
+}
+
+// Refresh lists the versions of the binary from its source, and updates its entry in the index
+func (i *Index) Refresh(ctx context.Context, b tool.Binary, max uint) ([]string, error) {
+	versions, err := tool.Versions(ctx, b, max)
+	if err != nil {
+		return nil, err
+	}
+	if err := i.put(
+		Entry{
+			FormatVersion: FormatVersion,
+			Binary:        b.Name(),
+			Versions:      versions,
+			Max:           max,
+			UpdatedAt:     time.Now(),
+		},
+	); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// fresh checks if the entry can be used in place of listing at most max versions again
+func (i *Index) fresh(e *Entry, max uint) bool {
+	return i.TTL > 0 && time.Since(e.UpdatedAt) < i.TTL && max <= e.Max
+}
+
+// put writes the entry to a temp file first, so that readers never see a partial entry
+func (i *Index) put(e Entry) error {
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(i.dir, e.Binary+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), i.entryPath(e.Binary))
+}
+
+func (i *Index) print(message string) {
+	if i.out != nil {
+		_, _ = fmt.Fprint(i.out, message)
+	}
+}
+
+func (i *Index) entryPath(binary string) string {
+	return filepath.Join(i.dir, binary+".json")
+}
+
+// migrate upgrades an entry written by an older kpkg release to the current format, and reports
+// whether it could. There is only one format so far, so there is nothing to upgrade yet
+func migrate(e *Entry) bool {
+	switch e.FormatVersion {
+	case FormatVersion:
+		return true
+	default:
+		return false
+	}
+}
+
+func head(versions []string, max uint) []string {
+	if uint(len(versions)) > max {
+		return versions[:max]
+	}
+	return versions
+}
+
+// MakeIndex creates an index in dir, creating the dir if it does not exist.
+// Warnings are printed to out, if not nil
+func MakeIndex(dir string, ttl time.Duration, out io.Writer) (*Index, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	return &Index{dir: dir, TTL: ttl, out: out}, nil
+}
@@ -0,0 +1,172 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// countingBinary lists fixed versions, and counts how often they are listed
+type countingBinary struct {
+	versions []string
+	err      error
+	count    int
+}
+
+func (c *countingBinary) Name() string {
+	return "a"
+}
+
+func (c *countingBinary) ShortDesc() string {
+	return "a"
+}
+
+func (c *countingBinary) LongDesc() string {
+	return "a"
+}
+
+func (c *countingBinary) MakeUrl(version string) (string, error) {
+	return "https://some.url/a-" + version, nil
+}
+
+func (c *countingBinary) Versions(max uint) ([]string, error) {
+	c.count++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return head(c.versions, max), nil
+}
+
+func (c *countingBinary) Extract(artifactPath, _ string) (string, error) {
+	return artifactPath, nil
+}
+
+func makeTestIndex(t *testing.T, ttl time.Duration) *Index {
+	idx, err := MakeIndex(t.TempDir(), ttl, nil)
+	if err != nil {
+		t.Fatalf("could not create index: %s", err)
+	}
+	return idx
+}
+
+func TestIndex_Versions(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		// maxes are asked for in order
+		maxes     []uint
+		wantCount int
+	}{
+		{
+			name:      "fresh entry is used",
+			ttl:       time.Hour,
+			maxes:     []uint{2, 2, 1},
+			wantCount: 1,
+		},
+		{
+			name:      "no ttl always lists versions",
+			ttl:       0,
+			maxes:     []uint{2, 2},
+			wantCount: 2,
+		},
+		{
+			name:      "more versions than listed",
+			ttl:       time.Hour,
+			maxes:     []uint{1, 2},
+			wantCount: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := makeTestIndex(t, tt.ttl)
+			b := &countingBinary{versions: []string{"2.0.0", "1.0.0"}}
+			for _, max := range tt.maxes {
+				versions, err := idx.Versions(context.Background(), b, max)
+				if err != nil {
+					t.Fatalf("Versions() error = %v, expected nil", err)
+				}
+				if want := head(b.versions, max); !reflect.DeepEqual(versions, want) {
+					t.Errorf("Versions() = %v, want %v", versions, want)
+				}
+			}
+			if b.count != tt.wantCount {
+				t.Errorf("listed versions %d times, want %d", b.count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestIndex_Versions_Stale(t *testing.T) {
+	var out bytes.Buffer
+	idx, err := MakeIndex(t.TempDir(), time.Hour, &out)
+	if err != nil {
+		t.Fatalf("could not create index: %s", err)
+	}
+	b := &countingBinary{versions: []string{"1.0.0"}}
+	if _, err := idx.Refresh(context.Background(), b, 10); err != nil {
+		t.Fatalf("Refresh() error = %v, expected nil", err)
+	}
+
+	// a stale entry is used if the versions can't be listed
+	b.err = errors.New("no network access")
+	versions, err := idx.WithTTL(0).Versions(context.Background(), b, 10)
+	if err != nil {
+		t.Fatalf("Versions() error = %v, expected nil", err)
+	}
+	if !reflect.DeepEqual(versions, []string{"1.0.0"}) {
+		t.Errorf("Versions() = %v, want [1.0.0]", versions)
+	}
+	if !strings.Contains(out.String(), "using versions listed at") {
+		t.Errorf("expected a warning about the stale entry, got %q", out.String())
+	}
+
+	// unless it holds fewer versions than asked for
+	if _, err := idx.WithTTL(0).Versions(context.Background(), b, 20); err == nil {
+		t.Errorf("Versions() error = nil, expected the listing error")
+	}
+}
+
+func TestIndex_Get(t *testing.T) {
+	tests := []struct {
+		name      string
+		contents  string
+		wantEntry bool
+	}{
+		{
+			name:      "current format",
+			contents:  `{"formatVersion": 1, "binary": "a", "versions": ["1.0.0"], "max": 10}`,
+			wantEntry: true,
+		},
+		{
+			name:     "newer format",
+			contents: `{"formatVersion": 2, "binary": "a", "versions": ["1.0.0"], "max": 10}`,
+		},
+		{
+			name:     "corrupt entry",
+			contents: `{"formatVersion": 1, "bin`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := makeTestIndex(t, time.Hour)
+			if err := ioutil.WriteFile(
+				idx.entryPath("a"), []byte(tt.contents), os.ModePerm,
+			); err != nil {
+				t.Fatalf("could not write entry: %s", err)
+			}
+			e, err := idx.Get("a")
+			if err != nil {
+				t.Fatalf("Get() error = %v, expected nil", err)
+			}
+			if (e != nil) != tt.wantEntry {
+				t.Errorf("Get() = %+v, wantEntry %v", e, tt.wantEntry)
+			}
+		})
+	}
+}
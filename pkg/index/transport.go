@@ -0,0 +1,175 @@
+package index
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// cachedResponse is a response stored by a revalidatingTransport
+type cachedResponse struct {
+	Url        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	// Body is gzip compressed, since lists of releases are large but compress well
+	Body []byte `json:"body"`
+}
+
+// revalidatingTransport stores GET responses that carry an ETag or a Last-Modified header, and
+// revalidates them with If-None-Match or If-Modified-Since the next time the same url is requested.
+// If the server answers with 304 Not Modified, the stored response is returned instead, which
+// saves downloading lists of versions that did not change. The GitHub API also doesn't count
+// such requests against its rate limit
+type revalidatingTransport struct {
+	dir  string
+	base http.RoundTripper
+}
+
+func (t *revalidatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	u := req.URL.String()
+	// a stored response that can't be read only costs a full response
+	cached, _ := t.read(u)
+	if cached != nil {
+		// a RoundTripper must not modify the request it was given
+		req = req.Clone(req.Context())
+		if etag := cached.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastModified := cached.Header.Get("Last-Modified"); lastModified != "" {
+			req.Header.Set("If-Modified-Since", lastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		resp.Body.Close()
+		return cached.response(req, resp.Header)
+	}
+	if resp.StatusCode != http.StatusOK ||
+		(resp.Header.Get("ETag") == "" && resp.Header.Get("Last-Modified") == "") {
+		return resp, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	// failing to store the response only costs a full response next time
+	_ = t.write(u, resp, body)
+	return resp, nil
+}
+
+func (t *revalidatingTransport) read(u string) (*cachedResponse, error) {
+	b, err := ioutil.ReadFile(t.path(u))
+	if err != nil {
+		return nil, err
+	}
+	var c cachedResponse
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	// guard against the unlikely collision of two urls
+	if c.Url != u {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+func (t *revalidatingTransport) write(u string, resp *http.Response, body []byte) error {
+	var compressed bytes.Buffer
+	w := gzip.NewWriter(&compressed)
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	b, err := json.Marshal(
+		cachedResponse{
+			Url:        u,
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       compressed.Bytes(),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(t.dir, "response.*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), t.path(u))
+}
+
+func (t *revalidatingTransport) path(u string) string {
+	sum := sha256.Sum256([]byte(u))
+	return filepath.Join(t.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// response rebuilds the stored response for req. The headers of the 304 response replace the
+// stored ones, since they are more recent, for example the remaining rate limit
+func (c *cachedResponse) response(req *http.Request, header http.Header) (*http.Response, error) {
+	r, err := gzip.NewReader(bytes.NewReader(c.Body))
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	h := c.Header.Clone()
+	for k, v := range header {
+		if k != "Content-Length" {
+			h[k] = v
+		}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", c.StatusCode, http.StatusText(c.StatusCode)),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// MakeRevalidatingTransport returns a transport that stores responses in dir, and revalidates them
+// when the same url is requested again. Requests are sent with base
+func MakeRevalidatingTransport(dir string, base http.RoundTripper) (http.RoundTripper, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &revalidatingTransport{dir: dir, base: base}, nil
+}
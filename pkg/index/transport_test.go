@@ -0,0 +1,82 @@
+package index
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRevalidatingTransport(t *testing.T) {
+	tests := []struct {
+		name string
+		etag string
+		// modified changes the body between requests
+		modified bool
+		// wantFull is the number of requests answered with the full body
+		wantFull int
+	}{
+		{
+			name:     "unchanged response is revalidated",
+			etag:     `"v1"`,
+			wantFull: 1,
+		},
+		{
+			name:     "changed response is downloaded",
+			etag:     `"v1"`,
+			modified: true,
+			wantFull: 2,
+		},
+		{
+			name:     "response without etag is not stored",
+			wantFull: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, etag, full := "releases", tt.etag, 0
+			ts := httptest.NewServer(
+				http.HandlerFunc(
+					func(rw http.ResponseWriter, r *http.Request) {
+						if etag != "" {
+							if r.Header.Get("If-None-Match") == etag {
+								rw.WriteHeader(http.StatusNotModified)
+								return
+							}
+							rw.Header().Set("ETag", etag)
+						}
+						full++
+						_, _ = rw.Write([]byte(body))
+					},
+				),
+			)
+			defer ts.Close()
+
+			transport, err := MakeRevalidatingTransport(t.TempDir(), nil)
+			if err != nil {
+				t.Fatalf("could not create transport: %s", err)
+			}
+			client := &http.Client{Transport: transport}
+			for i := 0; i < 2; i++ {
+				resp, err := client.Get(ts.URL)
+				if err != nil {
+					t.Fatalf("Get() error = %v, expected nil", err)
+				}
+				b, err := ioutil.ReadAll(resp.Body)
+				resp.Body.Close()
+				if err != nil {
+					t.Fatalf("could not read body: %s", err)
+				}
+				if resp.StatusCode != http.StatusOK || string(b) != body {
+					t.Errorf("Get() = %d %s, want 200 %s", resp.StatusCode, b, body)
+				}
+				if tt.modified {
+					body, etag = "new releases", `"v2"`
+				}
+			}
+			if full != tt.wantFull {
+				t.Errorf("answered %d requests with the full body, want %d", full, tt.wantFull)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+// pollInterval is how often a contended lock is retried
+const pollInterval = 50 * time.Millisecond
+
+// errLocked is returned by tryLock when the lock is held by another process
+var errLocked = errors.New("lock is held by another process")
+
+// Lock is an advisory lock on a file, shared between processes.
+// Advisory locks only exclude other processes that use the same locks,
+// so every kpkg process should go through this package before modifying the root
+type Lock struct {
+	f *os.File
+}
+
+// Acquire takes a lock on the file at the path, creating the file if it does not exist.
+// An exclusive lock excludes all other locks, while a shared lock only excludes exclusive ones.
+// If the lock can not be taken within the timeout, a LockTimeoutErr is returned.
+// Waiting for the lock stops early if ctx is done
+func Acquire(
+	ctx context.Context, p string, exclusive bool, timeout time.Duration,
+) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(p, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock(f, exclusive)
+		if err == nil {
+			return &Lock{f: f}, nil
+		}
+		if err != errLocked {
+			_ = f.Close()
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			_ = f.Close()
+			return nil, &kpkgerr.LockTimeoutErr{Path: p, Timeout: timeout}
+		}
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Release releases the lock. It is safe to call on a nil lock
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := unlock(l.f)
+	if e := l.f.Close(); e != nil && err == nil {
+		err = e
+	}
+	l.f = nil
+	return err
+}
@@ -0,0 +1,92 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+func TestAcquire(t *testing.T) {
+	tests := []struct {
+		name            string
+		firstExclusive  bool
+		secondExclusive bool
+		wantTimeout     bool
+	}{
+		{
+			name:            "exclusive blocks exclusive",
+			firstExclusive:  true,
+			secondExclusive: true,
+			wantTimeout:     true,
+		},
+		{
+			name:            "exclusive blocks shared",
+			firstExclusive:  true,
+			secondExclusive: false,
+			wantTimeout:     true,
+		},
+		{
+			name:            "shared blocks exclusive",
+			firstExclusive:  false,
+			secondExclusive: true,
+			wantTimeout:     true,
+		},
+		{
+			name:            "shared does not block shared",
+			firstExclusive:  false,
+			secondExclusive: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "locks", "a.lock")
+			first, err := Acquire(context.Background(), p, tt.firstExclusive, time.Second)
+			if err != nil {
+				t.Fatalf("Acquire() error = %v, expected nil", err)
+			}
+
+			second, err := Acquire(context.Background(), p, tt.secondExclusive, 100*time.Millisecond)
+			var timeoutErr *kpkgerr.LockTimeoutErr
+			if errors.As(err, &timeoutErr) != tt.wantTimeout {
+				t.Errorf(
+					"Acquire() error = %v, wantTimeout %v", err,
+					tt.wantTimeout,
+				)
+			}
+			if err := second.Release(); err != nil {
+				t.Errorf("Release() error = %v, expected nil", err)
+			}
+
+			// once released, the lock can be taken again
+			if err := first.Release(); err != nil {
+				t.Errorf("Release() error = %v, expected nil", err)
+			}
+			third, err := Acquire(context.Background(), p, true, 100*time.Millisecond)
+			if err != nil {
+				t.Errorf("Acquire() after release error = %v, expected nil", err)
+			}
+			if err := third.Release(); err != nil {
+				t.Errorf("Release() error = %v, expected nil", err)
+			}
+		})
+	}
+}
+
+func TestAcquire_Cancelled(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "a.lock")
+	held, err := Acquire(context.Background(), p, true, time.Second)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v, expected nil", err)
+	}
+	defer held.Release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := Acquire(ctx, p, true, time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire() error = %v, expected deadline exceeded", err)
+	}
+}
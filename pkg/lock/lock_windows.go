@@ -0,0 +1,27 @@
+package lock
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+func tryLock(f *os.File, exclusive bool) error {
+	var flags uint32 = windows.LOCKFILE_FAIL_IMMEDIATELY
+	if exclusive {
+		flags |= windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	err := windows.LockFileEx(
+		windows.Handle(f.Fd()), flags, 0, 1, 0, new(windows.Overlapped),
+	)
+	if err == windows.ERROR_LOCK_VIOLATION {
+		return errLocked
+	}
+	return err
+}
+
+func unlock(f *os.File) error {
+	return windows.UnlockFileEx(
+		windows.Handle(f.Fd()), 0, 1, 0, new(windows.Overlapped),
+	)
+}
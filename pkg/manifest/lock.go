@@ -0,0 +1,122 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// lockHeader is written at the top of every lockfile
+const lockHeader = "# generated by kpkg lock from " + FileName + ", do not edit\n"
+
+// Lock pins the binaries of a manifest to exact versions, and the artifacts of each platform
+// to their sha256 digests
+type Lock struct {
+	Tools map[string]LockedTool `yaml:"tools"`
+}
+
+// LockedTool is the version that a binary of the manifest was resolved to
+type LockedTool struct {
+	// Constraint is the version in the manifest that Version was resolved from
+	Constraint string `yaml:"constraint"`
+	Version    string `yaml:"version"`
+	// Artifacts are keyed by platform, like linux/amd64
+	Artifacts map[string]LockedArtifact `yaml:"artifacts"`
+}
+
+// LockedArtifact is the artifact that a binary is installed from on a platform
+type LockedArtifact struct {
+	Url    string `yaml:"url"`
+	Sha256 string `yaml:"sha256"`
+}
+
+// Platform returns the key of the artifacts of a platform
+func Platform(os, arch string) string {
+	return os + "/" + arch
+}
+
+// ReadLock reads the lockfile at path. A lockfile that does not exist is empty
+func ReadLock(path string) (*Lock, error) {
+	l := &Lock{Tools: map[string]LockedTool{}}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return l, nil
+		}
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(b, l); err != nil {
+		return nil, fmt.Errorf("could not parse lockfile %s: %w", path, err)
+	}
+	if l.Tools == nil {
+		l.Tools = map[string]LockedTool{}
+	}
+	return l, nil
+}
+
+// Write writes the lockfile to path, replacing it at once so that it is never left half written
+func (l *Lock) Write(path string) error {
+	b, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), LockFileName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(lockHeader + string(b)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Platforms returns the platforms that the lockfile has artifacts of, sorted
+func (l *Lock) Platforms() []string {
+	seen := map[string]bool{}
+	var platforms []string
+	for _, t := range l.Tools {
+		for p := range t.Artifacts {
+			if !seen[p] {
+				seen[p] = true
+				platforms = append(platforms, p)
+			}
+		}
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// Check checks that the lockfile was locked from the manifest, with the same binaries and
+// constraints, so that changes to the manifest are not silently ignored
+func (l *Lock) Check(m *Manifest) error {
+	for _, name := range m.Names() {
+		t, ok := l.Tools[name]
+		if !ok {
+			return fmt.Errorf("%s is not locked", name)
+		}
+		if t.Constraint != m.Tools[name] {
+			return fmt.Errorf(
+				"%s is locked for version %s, but the manifest asks for %s",
+				name, t.Constraint, m.Tools[name],
+			)
+		}
+	}
+	for name := range l.Tools {
+		if _, ok := m.Tools[name]; !ok {
+			return fmt.Errorf("%s is locked, but not in the manifest", name)
+		}
+	}
+	return nil
+}
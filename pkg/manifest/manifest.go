@@ -0,0 +1,111 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/Masterminds/semver"
+	"gopkg.in/yaml.v2"
+)
+
+// FileName is the name of the manifest that kpkg looks for by default
+const FileName = "kpkg.yaml"
+
+// LockFileName is the name of the lockfile, which is kept next to the manifest
+const LockFileName = "kpkg.lock"
+
+// Latest is the constraint that resolves to the latest version
+const Latest = "latest"
+
+// Manifest lists the binaries of a project, along with the versions they are constrained to.
+// For example:
+//
+//	tools:
+//	  kubectl: ~1.21
+//	  helm: 3.5.x
+//	  flux: latest
+type Manifest struct {
+	Tools map[string]string `yaml:"tools"`
+}
+
+// Load reads the manifest at path
+func Load(path string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := yaml.UnmarshalStrict(b, &m); err != nil {
+		return nil, fmt.Errorf("could not parse manifest %s: %w", path, err)
+	}
+	for name, constraint := range m.Tools {
+		if err := validateConstraint(constraint); err != nil {
+			return nil, fmt.Errorf("invalid version of %s in %s: %w", name, path, err)
+		}
+	}
+	return &m, nil
+}
+
+// Names returns the names of the binaries in the manifest, sorted
+func (m *Manifest) Names() []string {
+	names := make([]string, 0, len(m.Tools))
+	for name := range m.Tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LockPath returns the path of the lockfile of the manifest at path
+func LockPath(path string) string {
+	return filepath.Join(filepath.Dir(path), LockFileName)
+}
+
+func validateConstraint(constraint string) error {
+	if constraint == "" || constraint == Latest {
+		return nil
+	}
+	_, err := semver.NewConstraint(constraint)
+	return err
+}
+
+// Resolve returns the version that the constraint resolves to, out of versions that are sorted
+// like the versions of a Binary. An empty constraint or Latest resolves to the first version.
+// Versions that aren't semver, like the versions of mc, only match a constraint equal to them
+func Resolve(constraint string, versions []string) (string, error) {
+	if len(versions) == 0 {
+		return "", fmt.Errorf("no versions to resolve %s from", constraint)
+	}
+	if constraint == "" || constraint == Latest {
+		return versions[0], nil
+	}
+	for _, v := range versions {
+		if v == constraint {
+			return v, nil
+		}
+	}
+
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", err
+	}
+	var (
+		resolved string
+		max      *semver.Version
+	)
+	for _, v := range versions {
+		sv, err := semver.NewVersion(v)
+		if err != nil || !c.Check(sv) {
+			continue
+		}
+		if max == nil || sv.GreaterThan(max) {
+			resolved, max = v, sv
+		}
+	}
+	if max == nil {
+		return "", fmt.Errorf("no version matches %s", constraint)
+	}
+	return resolved, nil
+}
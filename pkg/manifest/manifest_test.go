@@ -0,0 +1,161 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	versions := []string{"1.22.0-beta.0", "1.21.2", "1.21.1", "1.20.5", "1.3.0"}
+	tests := []struct {
+		name       string
+		constraint string
+		versions   []string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "latest",
+			constraint: Latest,
+			want:       "1.22.0-beta.0",
+		},
+		{
+			name:       "tilde",
+			constraint: "~1.20",
+			want:       "1.20.5",
+		},
+		{
+			name:       "wildcard",
+			constraint: "1.21.x",
+			want:       "1.21.2",
+		},
+		{
+			name:       "exact",
+			constraint: "1.21.1",
+			want:       "1.21.1",
+		},
+		{
+			name:       "range skips pre-releases",
+			constraint: ">=1.21",
+			want:       "1.21.2",
+		},
+		{
+			name:       "no match",
+			constraint: "~2.0",
+			wantErr:    true,
+		},
+		{
+			name:       "not semver",
+			constraint: "RELEASE.2021-05-18T03-39-44Z",
+			versions:   []string{"RELEASE.2021-06-13T17-48-22Z", "RELEASE.2021-05-18T03-39-44Z"},
+			want:       "RELEASE.2021-05-18T03-39-44Z",
+		},
+		{
+			name:       "no versions",
+			constraint: Latest,
+			versions:   []string{},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vs := versions
+			if tt.versions != nil {
+				vs = tt.versions
+			}
+			got, err := Resolve(tt.constraint, vs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     map[string]string
+		wantErr  bool
+	}{
+		{
+			name:     "constraints",
+			contents: "tools:\n  kubectl: ~1.21\n  helm: 3.5.x\n  flux: latest\n  kind: 0.11\n",
+			want: map[string]string{
+				"kubectl": "~1.21", "helm": "3.5.x", "flux": "latest", "kind": "0.11",
+			},
+		},
+		{
+			name:     "invalid constraint",
+			contents: "tools:\n  kubectl: one\n",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown field",
+			contents: "tool:\n  kubectl: latest\n",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), FileName)
+			if err := ioutil.WriteFile(p, []byte(tt.contents), 0644); err != nil {
+				t.Fatal(err)
+			}
+			m, err := Load(p)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(m.Tools, tt.want) {
+				t.Errorf("Load() got = %v, want %v", m.Tools, tt.want)
+			}
+		})
+	}
+}
+
+func TestLock(t *testing.T) {
+	m := &Manifest{Tools: map[string]string{"helm": "3.5.x"}}
+	l := &Lock{
+		Tools: map[string]LockedTool{
+			"helm": {
+				Constraint: "3.5.x",
+				Version:    "3.5.4",
+				Artifacts: map[string]LockedArtifact{
+					Platform("linux", "amd64"):  {Url: "https://a", Sha256: "abc"},
+					Platform("darwin", "arm64"): {Url: "https://b", Sha256: "def"},
+				},
+			},
+		},
+	}
+	p := filepath.Join(t.TempDir(), LockFileName)
+	if err := l.Write(p); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	read, err := ReadLock(p)
+	if err != nil {
+		t.Fatalf("ReadLock() error = %v", err)
+	}
+	if !reflect.DeepEqual(read, l) {
+		t.Errorf("ReadLock() got = %+v, want %+v", read, l)
+	}
+	if got := read.Platforms(); !reflect.DeepEqual(got, []string{"darwin/arm64", "linux/amd64"}) {
+		t.Errorf("Platforms() got = %v", got)
+	}
+	if err := read.Check(m); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+
+	m.Tools["helm"] = "~3.6"
+	if err := read.Check(m); err == nil {
+		t.Errorf("Check() expected an error for a changed constraint")
+	}
+	m.Tools = map[string]string{"helm": "3.5.x", "kind": "latest"}
+	if err := read.Check(m); err == nil {
+		t.Errorf("Check() expected an error for a binary that is not locked")
+	}
+}
@@ -0,0 +1,106 @@
+package pin
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileName is the name of the files that pin versions of binaries for a dir and its subdirs.
+// Each line pins a binary, written as the name of the binary followed by its version, like
+// kubectl 1.20.0. Lines starting with # are comments
+const FileName = ".kpkg-version"
+
+// Read returns the versions pinned by the file at path, keyed by binary
+func Read(path string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pins := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf(
+				"invalid pin on line %d of %s, expected a binary and its version", line, path,
+			)
+		}
+		pins[fields[0]] = fields[1]
+	}
+	return pins, scanner.Err()
+}
+
+// Find returns the version of the binary pinned for dir, by the nearest pin file in dir or its
+// parents that pins the binary, along with the path of that file. The version is empty if no
+// file pins the binary
+func Find(dir, binary string) (version, path string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		p := filepath.Join(dir, FileName)
+		pins, err := Read(p)
+		if err != nil && !os.IsNotExist(err) {
+			return "", "", err
+		}
+		if v, ok := pins[binary]; ok {
+			return v, p, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", nil
+		}
+		dir = parent
+	}
+}
+
+// Set pins the version of the binary in the file at path, keeping the other lines of the file.
+// An empty version removes the pin. The file is removed once it holds nothing but comments
+func Set(path, binary, version string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	var lines []string
+	found, pins := false, 0
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) != 0 && fields[0] == binary {
+			if found || version == "" {
+				continue
+			}
+			found, line = true, binary+" "+version
+		}
+		if len(fields) != 0 && !strings.HasPrefix(fields[0], "#") {
+			pins++
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if !found && version != "" {
+		lines = append(lines, binary+" "+version)
+		pins++
+	}
+
+	if pins == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
@@ -0,0 +1,113 @@
+package pin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(nested, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(root, FileName):      "# versions of the cluster\nkubectl 1.19.4\nhelm 3.4.2\n",
+		filepath.Join(root, "a", FileName): "kubectl 1.20.0\n",
+	}
+	for p, contents := range files {
+		if err := ioutil.WriteFile(p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		dir, binary string
+		wantVersion string
+		wantPath    string
+	}{
+		{
+			name:        "nearest file",
+			dir:         nested,
+			binary:      "kubectl",
+			wantVersion: "1.20.0",
+			wantPath:    filepath.Join(root, "a", FileName),
+		},
+		{
+			name:        "parent file pins the binary",
+			dir:         nested,
+			binary:      "helm",
+			wantVersion: "3.4.2",
+			wantPath:    filepath.Join(root, FileName),
+		},
+		{
+			name:   "not pinned",
+			dir:    nested,
+			binary: "kind",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, path, err := Find(tt.dir, tt.binary)
+			if err != nil {
+				t.Fatalf("Find() error = %v", err)
+			}
+			if version != tt.wantVersion || path != tt.wantPath {
+				t.Errorf(
+					"Find() got = %s, %s, want %s, %s", version, path, tt.wantVersion, tt.wantPath,
+				)
+			}
+		})
+	}
+}
+
+func TestRead_Invalid(t *testing.T) {
+	p := filepath.Join(t.TempDir(), FileName)
+	if err := ioutil.WriteFile(p, []byte("kubectl\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(p); err == nil {
+		t.Errorf("Read() expected an error")
+	}
+}
+
+func TestSet(t *testing.T) {
+	p := filepath.Join(t.TempDir(), FileName)
+	if err := ioutil.WriteFile(p, []byte("# pins\nhelm 3.4.2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set(p, "kubectl", "1.19.4"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := Set(p, "helm", "3.5.0"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# pins\nhelm 3.5.0\nkubectl 1.19.4\n"; string(b) != want {
+		t.Errorf("Set() wrote %q, want %q", b, want)
+	}
+	pins, err := Read(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"helm": "3.5.0", "kubectl": "1.19.4"}; !reflect.DeepEqual(pins, want) {
+		t.Errorf("Read() got = %v, want %v", pins, want)
+	}
+
+	// removing the last pin removes the file
+	for _, binary := range []string{"helm", "kubectl"} {
+		if err := Set(p, binary, ""); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	if _, err := os.Stat(p); !os.IsNotExist(err) {
+		t.Errorf("expected the file to be removed, got %v", err)
+	}
+}
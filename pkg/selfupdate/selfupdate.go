@@ -0,0 +1,96 @@
+package selfupdate
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+)
+
+// BackupPath returns the path that the previous executable is kept at once exe is replaced
+func BackupPath(exe string) string {
+	return exe + ".previous"
+}
+
+// Replace replaces the executable at exe with the binary at src, keeping the previous executable
+// at BackupPath(exe). The binary is copied next to exe first, so that it is moved into place at once
+func Replace(exe, src string) error {
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	next := exe + ".next"
+	if err := copyFile(src, next, info.Mode().Perm()); err != nil {
+		_ = os.Remove(next)
+		return fmt.Errorf("could not copy the new executable next to %s: %w", exe, err)
+	}
+	if err := swap(exe, next); err != nil {
+		_ = os.Remove(next)
+		return err
+	}
+	return nil
+}
+
+// Rollback swaps the executable at exe with its backup, so that rolling back again undoes it
+func Rollback(exe string) error {
+	backup := BackupPath(exe)
+	if _, err := os.Stat(backup); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("there is no previous executable at %s to roll back to", backup)
+		}
+		return err
+	}
+	return swap(exe, backup)
+}
+
+// swap moves next into place at exe, and keeps the executable at exe as its backup. On unix, the
+// executable is linked to the backup before it is replaced, so that exe always exists. A running
+// executable can't be replaced on windows, but it can be renamed, so it is moved out of the way
+func swap(exe, next string) error {
+	backup := BackupPath(exe)
+	tmpBackup := backup + ".tmp"
+	_ = os.Remove(tmpBackup)
+	windows := runtime.GOOS == "windows"
+	if windows {
+		if err := os.Rename(exe, tmpBackup); err != nil {
+			return err
+		}
+	} else if err := os.Link(exe, tmpBackup); err != nil {
+		// the file system may not support hard links
+		info, statErr := os.Stat(exe)
+		if statErr != nil {
+			return statErr
+		}
+		if err := copyFile(exe, tmpBackup, info.Mode().Perm()); err != nil {
+			_ = os.Remove(tmpBackup)
+			return err
+		}
+	}
+
+	if err := os.Rename(next, exe); err != nil {
+		if !windows {
+			_ = os.Remove(tmpBackup)
+		} else if e := os.Rename(tmpBackup, exe); e != nil {
+			return fmt.Errorf("could not restore %s after error %s: %w", exe, err, e)
+		}
+		return fmt.Errorf("could not replace %s: %w", exe, err)
+	}
+	return os.Rename(tmpBackup, backup)
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
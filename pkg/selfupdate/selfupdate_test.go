@@ -0,0 +1,62 @@
+package selfupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplace(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "kpkg")
+	src := filepath.Join(dir, "new")
+	if err := ioutil.WriteFile(exe, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(src, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Rollback(exe); err == nil {
+		t.Errorf("Rollback() expected an error without a previous executable")
+	}
+	if err := Replace(exe, src); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	assertContents(t, exe, "new")
+	assertContents(t, BackupPath(exe), "old")
+	if info, err := os.Stat(exe); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("expected the executable to keep its mode, got %v, %v", info.Mode(), err)
+	}
+
+	// rolling back swaps the executables, so rolling back again undoes it
+	if err := Rollback(exe); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	assertContents(t, exe, "old")
+	assertContents(t, BackupPath(exe), "new")
+	if err := Rollback(exe); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	assertContents(t, exe, "new")
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected only the executable, its backup and the source in %s, got %d files", dir, len(entries))
+	}
+}
+
+func assertContents(t *testing.T, path, want string) {
+	t.Helper()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != want {
+		t.Errorf("%s contains %q, want %q", path, b, want)
+	}
+}
@@ -55,8 +55,12 @@ func (l argocdTool) MakeUrl(version string) (string, error) {
 
 func MakeBinary(os, arch string) tool.Binary {
 	return argocdTool{
-		arch:              arch,
-		os:                os,
-		GithubReleaseTool: tool.MakeGithubReleaseTool("argoproj", "argo-cd"),
+		arch: arch,
+		os:   os,
+		GithubReleaseTool: tool.MakeGithubReleaseTool(
+			"argoproj", "argo-cd",
+		).WithVerifiers(
+			tool.MakeCosignVerifier(".sig", tool.VerifyIfPresent),
+		),
 	}
 }
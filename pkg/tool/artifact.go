@@ -0,0 +1,80 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+// Artifact is the artifact that a version of a binary is installed from
+type Artifact struct {
+	Url    string
+	Sha256 string
+}
+
+// ResolveArtifact downloads the artifact of a version of a binary, verifies it like Install does,
+// and returns its url along with its sha256 digest. The url is the one of the binary, even if
+// opts.Rewriter downloads it from a mirror. The version must be exact, it is not resolved
+func ResolveArtifact(
+	ctx context.Context, version string, b Binary, f download.FileFetcher, opts InstallOptions,
+) (a Artifact, err error) {
+	if opts.Rewriter != nil {
+		f, err = download.MakeRewriteFileFetcher(opts.Rewriter, f)
+		if err != nil {
+			return Artifact{}, err
+		}
+	}
+	url, err := b.MakeUrl(version)
+	if err != nil {
+		return Artifact{}, err
+	}
+
+	var artifact, expectedSum string
+	if c, ok := b.(Checksummer); ok && !opts.SkipChecksum {
+		artifact, expectedSum, err = expectedChecksum(ctx, c, version, opts.Keyring, f, opts.output())
+		var noChecksumErr *kpkgerr.NoChecksumErr
+		if errors.As(err, &noChecksumErr) {
+			err = nil
+		}
+		if err != nil {
+			return Artifact{}, err
+		}
+	}
+
+	fetchCtx := ctx
+	if expectedSum != "" {
+		fetchCtx = download.WithSha256(ctx, expectedSum)
+	}
+	artifactPath, err := f.FetchFile(fetchCtx, url)
+	if err != nil {
+		return Artifact{}, err
+	}
+	defer func() {
+		if e := os.Remove(artifactPath); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if expectedSum != "" {
+		if err := compareChecksum(artifactPath, artifact, expectedSum); err != nil {
+			return Artifact{}, err
+		}
+	}
+	if v, ok := b.(Verifiable); ok {
+		for _, verifier := range v.Verifiers() {
+			if err := verifier.Verify(ctx, url, artifactPath, opts.PublicKey, f, opts.output()); err != nil {
+				return Artifact{}, err
+			}
+		}
+	}
+
+	sum, err := fileSha256(artifactPath)
+	if err != nil {
+		return Artifact{}, fmt.Errorf("could not hash artifact of %s: %w", b.Name(), err)
+	}
+	return Artifact{Url: url, Sha256: sum}, nil
+}
@@ -0,0 +1,180 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+func TestResolveArtifact(t *testing.T) {
+	a, err := ResolveArtifact(
+		context.Background(), "1.0.0", testBinary{},
+		testContentsFileFetcher{dir: t.TempDir(), contents: "hello"}, InstallOptions{},
+	)
+	if err != nil {
+		t.Fatalf("ResolveArtifact() error = %v", err)
+	}
+	want := Artifact{Url: "https://some.url/a-1.0.0", Sha256: helloSha256}
+	if a != want {
+		t.Errorf("ResolveArtifact() got = %+v, want %+v", a, want)
+	}
+}
+
+func TestInstall_Sha256(t *testing.T) {
+	tests := []struct {
+		name    string
+		sha256  string
+		wantErr bool
+	}{
+		{
+			name:   "matching digest",
+			sha256: helloSha256,
+		},
+		{
+			name:    "mismatched digest",
+			sha256:  "abc",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := config.CreatePath(t.TempDir())
+			if err != nil {
+				t.Fatalf("could not create root: %s", err)
+			}
+			_, err = Install(
+				context.Background(), root, "1.0.0", testBinary{},
+				testContentsFileFetcher{dir: t.TempDir(), contents: "hello"},
+				download.MakeLocalFileFetcher(), InstallOptions{Max: 20, Sha256: tt.sha256},
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Install() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var checksumErr *kpkgerr.ChecksumErr
+			if tt.wantErr && !errors.As(err, &checksumErr) {
+				t.Errorf("Install() error = %v, expected a checksum error", err)
+			}
+			if installed, _ := Installed(root, "a", "1.0.0"); installed == tt.wantErr {
+				t.Errorf("Installed() = %v, wantErr %v", installed, tt.wantErr)
+			}
+		})
+	}
+}
+
+// failingVersionSource fails to list versions, for installs that should not list any
+type failingVersionSource struct{}
+
+func (failingVersionSource) Versions(_ context.Context, b Binary, _ uint) ([]string, error) {
+	return nil, errors.New("unexpected listing of the versions of " + b.Name())
+}
+
+func (s failingVersionSource) Refresh(ctx context.Context, b Binary, max uint) ([]string, error) {
+	return s.Versions(ctx, b, max)
+}
+
+func TestInstall_Locked(t *testing.T) {
+	root, err := config.CreatePath(t.TempDir())
+	if err != nil {
+		t.Fatalf("could not create root: %s", err)
+	}
+	// 1.0.0 is not one of the latest Max versions, but is pinned by its digest
+	if _, err := Install(
+		context.Background(), root, "1.0.0", multiVersionBinary{},
+		testContentsFileFetcher{dir: t.TempDir(), contents: "hello"}, download.MakeLocalFileFetcher(),
+		InstallOptions{Max: 1, Sha256: helloSha256, Versions: failingVersionSource{}},
+	); err != nil {
+		t.Fatalf("Install() error = %v, expected the locked version to install", err)
+	}
+	if _, err := Install(
+		context.Background(), root, "2.0.0", multiVersionBinary{},
+		testContentsFileFetcher{dir: t.TempDir(), contents: "hello"}, download.MakeLocalFileFetcher(),
+		InstallOptions{Max: 20},
+	); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	// relinking the installed version lists and downloads nothing
+	if _, err := Install(
+		context.Background(), root, "1.0.0", multiVersionBinary{},
+		failingFileFetcher{}, download.MakeLocalFileFetcher(),
+		InstallOptions{Max: 1, Versions: failingVersionSource{}},
+	); err != nil {
+		t.Fatalf("Install() error = %v, expected the installed version to be relinked", err)
+	}
+	if v, err := LinkedVersion(root, "a"); err != nil || v != "1.0.0" {
+		t.Errorf("LinkedVersion() = %s, %v, want 1.0.0", v, err)
+	}
+}
+
+func TestInstall_NoLink(t *testing.T) {
+	root, err := config.CreatePath(t.TempDir())
+	if err != nil {
+		t.Fatalf("could not create root: %s", err)
+	}
+	for _, version := range []string{"1.0.0", "2.0.0"} {
+		if _, err := Install(
+			context.Background(), root, version, multiVersionBinary{},
+			testContentsFileFetcher{dir: t.TempDir(), contents: version},
+			download.MakeLocalFileFetcher(), InstallOptions{Max: 20, NoLink: version == "2.0.0"},
+		); err != nil {
+			t.Fatalf("Install() error = %v", err)
+		}
+	}
+	if installed, err := Installed(root, "a", "2.0.0"); err != nil || !installed {
+		t.Errorf("Installed() = %v, %v, want the version installed", installed, err)
+	}
+	if v, err := LinkedVersion(root, "a"); err != nil || v != "1.0.0" {
+		t.Errorf("LinkedVersion() = %s, %v, want the link left at 1.0.0", v, err)
+	}
+}
+
+func TestInstall_RequireChecksum(t *testing.T) {
+	tests := []struct {
+		name           string
+		sha256         string
+		skipChecksum   bool
+		wantUnverified bool
+	}{
+		{
+			name:           "no published checksum",
+			wantUnverified: true,
+		},
+		{
+			name:   "pinned digest",
+			sha256: helloSha256,
+		},
+		{
+			name:           "skipped checksum",
+			skipChecksum:   true,
+			wantUnverified: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := config.CreatePath(t.TempDir())
+			if err != nil {
+				t.Fatalf("could not create root: %s", err)
+			}
+			_, err = Install(
+				context.Background(), root, "1.0.0", testBinary{},
+				testContentsFileFetcher{dir: t.TempDir(), contents: "hello"},
+				download.MakeLocalFileFetcher(), InstallOptions{
+					Max: 20, Sha256: tt.sha256, SkipChecksum: tt.skipChecksum, RequireChecksum: true,
+				},
+			)
+			var unverifiedErr *kpkgerr.UnverifiedErr
+			if errors.As(err, &unverifiedErr) != tt.wantUnverified {
+				t.Fatalf("Install() error = %v, wantUnverified %v", err, tt.wantUnverified)
+			}
+			if !tt.wantUnverified && err != nil {
+				t.Fatalf("Install() error = %v", err)
+			}
+			if installed, _ := Installed(root, "a", "1.0.0"); installed == tt.wantUnverified {
+				t.Errorf("Installed() = %v, wantUnverified %v", installed, tt.wantUnverified)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package tool
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+// Checksummer is an optional interface that a Binary can implement if its releases
+// publish sha256 checksums, like a SHA256SUMS or a .sha256 file. If a Binary implements it,
+// the downloaded artifact is verified before it is installed
+type Checksummer interface {
+	// ChecksumUrl returns the url of the checksum file for a given version,
+	// along with the name of the artifact as it is listed in the checksum file
+	ChecksumUrl(version string) (url, artifact string, err error)
+}
+
+// expectedChecksum fetches the checksum file published for the version, and returns
+// the name of the artifact along with its expected digest.
+// If the checksummer also implements ChecksumSigner, the checksum file is only trusted
+// once its signature is verified. What is being verified is printed to out
+func expectedChecksum(
+	ctx context.Context, c Checksummer, version string, keyring openpgp.EntityList,
+	f download.FileFetcher, out io.Writer,
+) (artifact, expected string, err error) {
+	url, artifact, err := c.ChecksumUrl(version)
+	if err != nil {
+		return "", "", err
+	}
+
+	checksumPath, err := f.FetchFile(ctx, url)
+	if err != nil {
+		return "", "", fmt.Errorf("could not fetch checksum file: %w", err)
+	}
+	defer os.Remove(checksumPath)
+
+	if signer, ok := c.(ChecksumSigner); ok {
+		fmt.Fprintln(out, "verifying checksum file signature")
+		if err := verifySignature(
+			ctx, signer, version, checksumPath, keyring, f,
+		); err != nil {
+			return "", "", err
+		}
+	}
+
+	contents, err := ioutil.ReadFile(checksumPath)
+	if err != nil {
+		return "", "", err
+	}
+	expected, err = parseChecksum(contents, artifact)
+	if err != nil {
+		return "", "", err
+	}
+	return artifact, expected, nil
+}
+
+// compareChecksum compares the expected digest against the digest of the artifact at artifactPath
+func compareChecksum(artifactPath, artifact, expected string) error {
+	actual, err := fileSha256(artifactPath)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(expected, actual) {
+		return &kpkgerr.ChecksumErr{
+			Artifact: artifact,
+			Expected: expected,
+			Actual:   actual,
+		}
+	}
+	return nil
+}
+
+// parseChecksum finds the digest of the artifact in the contents of a checksum file.
+// Checksum files either list a digest per artifact in the format output by sha256sum,
+// or contain a lone digest for a single artifact
+func parseChecksum(contents []byte, artifact string) (string, error) {
+	var lines [][]string
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		lines = append(lines, fields)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if len(lines) == 1 && len(lines[0]) == 1 {
+		return lines[0][0], nil
+	}
+
+	for _, fields := range lines {
+		if len(fields) != 2 {
+			continue
+		}
+		// sha256sum marks files read in binary mode with an asterisk
+		if strings.TrimPrefix(fields[1], "*") == artifact {
+			return fields[0], nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find checksum for artifact %s", artifact)
+}
+
+// fileSha256 returns the hex encoded sha256 digest of the file at the path
+func fileSha256(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,173 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+const helloSha256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+
+func Test_parseChecksum(t *testing.T) {
+	type args struct {
+		contents string
+		artifact string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "sums file",
+			args: args{
+				contents: "abc  a.tar.gz\n" + helloSha256 + "  b.tar.gz\n",
+				artifact: "b.tar.gz",
+			},
+			want: helloSha256,
+		},
+		{
+			name: "sums file binary mode",
+			args: args{
+				contents: helloSha256 + " *b.zip\n",
+				artifact: "b.zip",
+			},
+			want: helloSha256,
+		},
+		{
+			name: "lone digest",
+			args: args{
+				contents: helloSha256 + "\n",
+				artifact: "b.zip",
+			},
+			want: helloSha256,
+		},
+		{
+			name: "missing artifact",
+			args: args{
+				contents: "abc  a.tar.gz\ndef  b.tar.gz\n",
+				artifact: "c.tar.gz",
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty file",
+			args: args{
+				contents: "",
+				artifact: "c.tar.gz",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseChecksum([]byte(tt.args.contents), tt.args.artifact)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseChecksum() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseChecksum() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type testChecksummer struct {
+	url, artifact string
+}
+
+func (t testChecksummer) ChecksumUrl(_ string) (string, string, error) {
+	return t.url, t.artifact, nil
+}
+
+// checksumBinary is a testBinary that publishes a checksum file
+type checksumBinary struct {
+	testBinary
+	testChecksummer
+}
+
+// testFilesFileFetcher serves files by the last element of their url
+type testFilesFileFetcher struct {
+	files map[string]string
+	dir   string
+}
+
+func (t testFilesFileFetcher) FetchFile(_ context.Context, u string) (string, error) {
+	contents, ok := t.files[path.Base(u)]
+	if !ok {
+		return "", fmt.Errorf("%s not found", u)
+	}
+	p := filepath.Join(t.dir, path.Base(u))
+	return p, ioutil.WriteFile(p, []byte(contents), os.ModePerm)
+}
+
+func TestInstall_Checksum(t *testing.T) {
+	tests := []struct {
+		name         string
+		sums         string
+		wantErr      bool
+		wantSumError bool
+	}{
+		{
+			name: "matching checksum",
+			sums: helloSha256 + "  a-1.0.0\n",
+		},
+		{
+			name:         "mismatched checksum",
+			sums:         "abc  a-1.0.0\n",
+			wantErr:      true,
+			wantSumError: true,
+		},
+		{
+			name:    "artifact not listed",
+			sums:    helloSha256 + "  other.tar.gz\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := config.CreatePath(t.TempDir())
+			if err != nil {
+				t.Fatalf("could not create root: %s", err)
+			}
+			b := checksumBinary{
+				testChecksummer: testChecksummer{
+					url:      "https://some.url/SHA256SUMS",
+					artifact: "a-1.0.0",
+				},
+			}
+			f := testFilesFileFetcher{
+				files: map[string]string{"SHA256SUMS": tt.sums, "a-1.0.0": "hello"},
+				dir:   t.TempDir(),
+			}
+			_, err = Install(
+				context.Background(), root, "1.0.0", b, f, download.MakeLocalFileFetcher(),
+				InstallOptions{Max: 20},
+			)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Install() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var checksumErr *kpkgerr.ChecksumErr
+			if errors.As(err, &checksumErr) != tt.wantSumError {
+				t.Errorf(
+					"Install() error = %v, wantSumError %v", err,
+					tt.wantSumError,
+				)
+			}
+			if installed, _ := Installed(root, "a", "1.0.0"); installed == tt.wantErr {
+				t.Errorf("Installed() = %v, wantErr %v", installed, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package civo
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Masterminds/semver"
@@ -107,7 +108,11 @@ func (l civoTool) MakeUrl(version string) (string, error) {
 }
 
 func (l civoTool) Versions(max uint) ([]string, error) {
-	versions, err := l.GithubReleaseTool.Versions(max)
+	return l.VersionsContext(context.Background(), max)
+}
+
+func (l civoTool) VersionsContext(ctx context.Context, max uint) ([]string, error) {
+	versions, err := l.GithubReleaseTool.VersionsContext(ctx, max)
 	if err != nil {
 		return nil, err
 	}
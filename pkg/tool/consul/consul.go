@@ -6,6 +6,7 @@ import (
 	kpkgerr "github.com/spachava753/kpkg/pkg/error"
 	"github.com/spachava753/kpkg/pkg/tool"
 	"os"
+	"path"
 	"path/filepath"
 )
 
@@ -13,6 +14,7 @@ type consulTool struct {
 	arch,
 	os string
 	tool.GithubReleaseTool
+	tool.HashicorpReleaseTool
 }
 
 func (l consulTool) Extract(artifactPath, _ string) (string, error) {
@@ -73,10 +75,20 @@ func (l consulTool) MakeUrl(version string) (string, error) {
 	return url, nil
 }
 
+func (l consulTool) ChecksumUrl(version string) (string, string, error) {
+	url, err := l.MakeUrl(version)
+	if err != nil {
+		return "", "", err
+	}
+	sumsUrl, err := l.MakeSumsUrl(version)
+	return sumsUrl, path.Base(url), err
+}
+
 func MakeBinary(os, arch string) tool.Binary {
 	return consulTool{
-		arch:              arch,
-		os:                os,
-		GithubReleaseTool: tool.MakeGithubReleaseTool("hashicorp", "consul"),
+		arch:                 arch,
+		os:                   os,
+		GithubReleaseTool:    tool.MakeGithubReleaseTool("hashicorp", "consul"),
+		HashicorpReleaseTool: tool.MakeHashicorpReleaseTool("consul"),
 	}
 }
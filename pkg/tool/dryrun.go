@@ -0,0 +1,52 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+// printDryRun prints what installing the version of the binary would download, after rewriting
+// the urls, so that rewrite rules can be checked without downloading anything
+func printDryRun(b Binary, binary, version string, opts InstallOptions) error {
+	out := opts.output()
+	fmt.Fprintf(out, "dry run, %s %s is not installed\n", binary, version)
+	u, err := b.MakeUrl(version)
+	if err != nil {
+		return err
+	}
+	if opts.FromFile != "" {
+		_, artifact, err := localArtifactPaths(opts.FromFile, u)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "artifact: %s\n", artifact)
+		return nil
+	}
+	printDryRunUrl(out, "artifact", u, opts.Rewriter)
+
+	if c, ok := b.(Checksummer); ok && !opts.SkipChecksum {
+		checksumUrl, _, err := c.ChecksumUrl(version)
+		var noChecksumErr *kpkgerr.NoChecksumErr
+		if errors.As(err, &noChecksumErr) {
+			fmt.Fprintf(out, "checksum: none, %s\n", err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		printDryRunUrl(out, "checksum", checksumUrl, opts.Rewriter)
+	}
+	return nil
+}
+
+func printDryRunUrl(out io.Writer, name, u string, r *download.Rewriter) {
+	if rewritten := r.Rewrite(u); rewritten != u {
+		fmt.Fprintf(out, "%s: %s (rewritten from %s)\n", name, rewritten, u)
+		return
+	}
+	fmt.Fprintf(out, "%s: %s\n", name, u)
+}
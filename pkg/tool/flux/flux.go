@@ -56,10 +56,14 @@ func (l fluxTool) MakeUrl(version string) (string, error) {
 }
 
 func (l fluxTool) Versions(max uint) ([]string, error) {
-	client := github.NewClient(nil)
+	return l.VersionsContext(context.Background(), max)
+}
+
+func (l fluxTool) VersionsContext(ctx context.Context, max uint) ([]string, error) {
+	client := tool.GithubClient()
 	var resp *github.Response
 	releases, resp, err := client.Repositories.ListReleases(
-		context.Background(), l.Owner, l.Repo, nil,
+		ctx, l.Owner, l.Repo, nil,
 	)
 	if err != nil {
 		return nil, err
@@ -67,7 +71,7 @@ func (l fluxTool) Versions(max uint) ([]string, error) {
 	var r []*github.RepositoryRelease
 	for resp != nil && resp.NextPage != resp.LastPage && uint(len(releases)) < max {
 		r, resp, err = client.Repositories.ListReleases(
-			context.Background(), l.Owner, l.Repo, &github.ListOptions{
+			ctx, l.Owner, l.Repo, &github.ListOptions{
 				Page:    resp.NextPage,
 				PerPage: int(max) - len(releases),
 			},
@@ -114,8 +118,12 @@ func (l fluxTool) Versions(max uint) ([]string, error) {
 
 func MakeBinary(os, arch string) tool.Binary {
 	return fluxTool{
-		arch:              arch,
-		os:                os,
-		GithubReleaseTool: tool.MakeGithubReleaseTool("fluxcd", "flux"),
+		arch: arch,
+		os:   os,
+		GithubReleaseTool: tool.MakeGithubReleaseTool(
+			"fluxcd", "flux",
+		).WithVerifiers(
+			tool.MakeCosignVerifier(".sig", tool.VerifyIfPresent),
+		),
 	}
 }
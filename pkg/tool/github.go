@@ -0,0 +1,134 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+// GithubOptions configures the client that binaries share to call the GitHub API
+type GithubOptions struct {
+	// Token authenticates requests, which raises the rate limit of the GitHub API.
+	// If empty, the GITHUB_TOKEN or GH_TOKEN env vars are used
+	Token string
+	// Transport sends requests, or http.DefaultTransport if nil
+	Transport http.RoundTripper
+	// BaseUrl is the base url of the GitHub API, for example https://github.example.com/api/v3/
+	// for GitHub Enterprise. If empty, https://api.github.com/ is used
+	BaseUrl string
+}
+
+// githubClient is shared by all binaries, so that connections are reused, and the rate limit
+// reported by one response is known when listing the versions of the next binary
+var githubClient, githubAuthenticated, _ = newGithubClient(GithubOptions{})
+
+// ConfigureGithub replaces the client that binaries call the GitHub API with.
+// It must be called before any versions are listed
+func ConfigureGithub(opts GithubOptions) error {
+	client, authenticated, err := newGithubClient(opts)
+	if err != nil {
+		return err
+	}
+	githubClient, githubAuthenticated = client, authenticated
+	return nil
+}
+
+// GithubClient returns the client that binaries share to call the GitHub API
+func GithubClient() *github.Client {
+	return githubClient
+}
+
+// GithubTokenFromEnv returns the GitHub token set in the env, if any
+func GithubTokenFromEnv() string {
+	for _, env := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := os.Getenv(env); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+func newGithubClient(opts GithubOptions) (*github.Client, bool, error) {
+	transport := opts.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	token := opts.Token
+	if token == "" {
+		token = GithubTokenFromEnv()
+	}
+	httpClient := &http.Client{Transport: transport}
+	client := github.NewClient(httpClient)
+	if opts.BaseUrl != "" {
+		baseUrl, err := url.Parse(opts.BaseUrl)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid GitHub API url %s: %w", opts.BaseUrl, err)
+		}
+		// the paths of requests are resolved relative to the base url
+		if !strings.HasSuffix(baseUrl.Path, "/") {
+			baseUrl.Path += "/"
+		}
+		client.BaseURL = baseUrl
+	}
+	if token != "" {
+		httpClient.Transport = &githubTokenTransport{
+			token: token,
+			api:   client.BaseURL,
+			base:  transport,
+		}
+	}
+	return client, token != "", nil
+}
+
+// githubTokenTransport authenticates requests to the GitHub API. Requests to other hosts,
+// like redirects to where release assets are stored, are sent without the token
+type githubTokenTransport struct {
+	token string
+	// api is the base url of the GitHub API
+	api  *url.URL
+	base http.RoundTripper
+}
+
+func (g *githubTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Host != g.api.Host || req.Header.Get("Authorization") != "" {
+		return g.base.RoundTrip(req)
+	}
+	// a RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "token "+g.token)
+	return g.base.RoundTrip(req)
+}
+
+// githubErr converts rate limit errors of the GitHub API into a RateLimitErr,
+// which tells when the limit resets and how to raise it
+func githubErr(err error) error {
+	var rateErr *github.RateLimitError
+	if errors.As(err, &rateErr) {
+		return &kpkgerr.RateLimitErr{
+			Reset:         rateErr.Rate.Reset.Time,
+			Authenticated: githubAuthenticated,
+			Err:           err,
+		}
+	}
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &abuseErr) {
+		var reset time.Time
+		if abuseErr.RetryAfter != nil {
+			reset = time.Now().Add(*abuseErr.RetryAfter)
+		}
+		return &kpkgerr.RateLimitErr{
+			Reset:         reset,
+			Authenticated: githubAuthenticated,
+			Err:           err,
+		}
+	}
+	return err
+}
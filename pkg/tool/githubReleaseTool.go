@@ -13,6 +13,7 @@ import (
 
 type GithubReleaseTool struct {
 	Owner, Repo string
+	verifiers   []Verifier
 }
 
 func (l GithubReleaseTool) MakeReleaseUrl() string {
@@ -21,15 +22,30 @@ func (l GithubReleaseTool) MakeReleaseUrl() string {
 	)
 }
 
+// WithVerifiers returns a copy of the release tool that checks downloaded artifacts
+// with the given verifiers
+func (l GithubReleaseTool) WithVerifiers(v ...Verifier) GithubReleaseTool {
+	l.verifiers = append(append([]Verifier{}, l.verifiers...), v...)
+	return l
+}
+
+func (l GithubReleaseTool) Verifiers() []Verifier {
+	return l.verifiers
+}
+
 func (l GithubReleaseTool) Extract(artifactPath, _ string) (string, error) {
 	return artifactPath, nil
 }
 
 func (l GithubReleaseTool) Versions(max uint) ([]string, error) {
-	client := github.NewClient(nil)
+	return l.VersionsContext(context.Background(), max)
+}
+
+func (l GithubReleaseTool) VersionsContext(ctx context.Context, max uint) ([]string, error) {
+	client := GithubClient()
 	var resp *github.Response
 	releases, resp, err := client.Repositories.ListReleases(
-		context.Background(), l.Owner, l.Repo, nil,
+		ctx, l.Owner, l.Repo, nil,
 	)
 	if err != nil {
 		return nil, err
@@ -37,7 +53,7 @@ func (l GithubReleaseTool) Versions(max uint) ([]string, error) {
 	var r []*github.RepositoryRelease
 	for resp != nil && resp.NextPage != resp.LastPage && uint(len(releases)) < max {
 		r, resp, err = client.Repositories.ListReleases(
-			context.Background(), l.Owner, l.Repo, &github.ListOptions{
+			ctx, l.Owner, l.Repo, &github.ListOptions{
 				Page:    resp.NextPage,
 				PerPage: int(max) - len(releases),
 			},
@@ -83,6 +99,7 @@ func (l GithubReleaseTool) Versions(max uint) ([]string, error) {
 
 func MakeGithubReleaseTool(org, repo string) GithubReleaseTool {
 	return GithubReleaseTool{
-		org, repo,
+		Owner: org,
+		Repo:  repo,
 	}
 }
@@ -0,0 +1,141 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+// githubTestBinary lists its versions from the releases of a GitHub repo
+type githubTestBinary struct {
+	GithubReleaseTool
+}
+
+func (g githubTestBinary) Name() string {
+	return "a"
+}
+
+func (g githubTestBinary) ShortDesc() string {
+	return "a"
+}
+
+func (g githubTestBinary) LongDesc() string {
+	return "a"
+}
+
+func (g githubTestBinary) MakeUrl(version string) (string, error) {
+	return "https://some.url/a-" + version, nil
+}
+
+// unsetenv unsets the env var until the test is done
+func unsetenv(t *testing.T, key string) {
+	if v, ok := os.LookupEnv(key); ok {
+		t.Cleanup(func() { os.Setenv(key, v) })
+	}
+	os.Unsetenv(key)
+}
+
+func TestGithubClient_RateLimit(t *testing.T) {
+	reset := time.Now().Add(time.Hour).Truncate(time.Second)
+	tests := []struct {
+		name  string
+		token string
+		// wantAuth is the Authorization header the API should receive
+		wantAuth string
+	}{
+		{
+			name: "unauthenticated",
+		},
+		{
+			name:     "authenticated",
+			token:    "abc",
+			wantAuth: "token abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetenv(t, "GITHUB_TOKEN")
+			unsetenv(t, "GH_TOKEN")
+			var gotAuth, gotPath string
+			ts := httptest.NewServer(
+				http.HandlerFunc(
+					func(rw http.ResponseWriter, r *http.Request) {
+						gotAuth, gotPath = r.Header.Get("Authorization"), r.URL.Path
+						rw.Header().Set("X-RateLimit-Limit", "60")
+						rw.Header().Set("X-RateLimit-Remaining", "0")
+						rw.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+						rw.WriteHeader(http.StatusForbidden)
+						_, _ = rw.Write([]byte(`{"message": "API rate limit exceeded"}`))
+					},
+				),
+			)
+			defer ts.Close()
+
+			defer ConfigureGithub(GithubOptions{})
+			if err := ConfigureGithub(
+				GithubOptions{Token: tt.token, BaseUrl: ts.URL + "/api/v3"},
+			); err != nil {
+				t.Fatalf("ConfigureGithub() error = %v, expected nil", err)
+			}
+
+			_, err := Versions(
+				context.Background(), githubTestBinary{MakeGithubReleaseTool("a", "b")}, 10,
+			)
+			var rateErr *kpkgerr.RateLimitErr
+			if !errors.As(err, &rateErr) {
+				t.Fatalf("Versions() error = %v, expected a rate limit error", err)
+			}
+			if !rateErr.Reset.Equal(reset) {
+				t.Errorf("Reset = %s, want %s", rateErr.Reset, reset)
+			}
+			if rateErr.Authenticated != (tt.token != "") {
+				t.Errorf("Authenticated = %v, want %v", rateErr.Authenticated, tt.token != "")
+			}
+			if strings.Contains(err.Error(), "GITHUB_TOKEN") == rateErr.Authenticated {
+				t.Errorf("Error() = %s, expected to mention GITHUB_TOKEN only when unauthenticated", err)
+			}
+			if gotAuth != tt.wantAuth {
+				t.Errorf("Authorization = %q, want %q", gotAuth, tt.wantAuth)
+			}
+			if gotPath != "/api/v3/repos/a/b/releases" {
+				t.Errorf("requested %s, expected the releases under the base url", gotPath)
+			}
+		})
+	}
+}
+
+func Test_githubTokenTransport(t *testing.T) {
+	var gotAuth string
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(rw http.ResponseWriter, r *http.Request) {
+				gotAuth = r.Header.Get("Authorization")
+			},
+		),
+	)
+	defer ts.Close()
+
+	client := &http.Client{
+		Transport: &githubTokenTransport{
+			token: "abc", api: &url.URL{Scheme: "https", Host: "api.github.com"},
+			base: http.DefaultTransport,
+		},
+	}
+	resp, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v, expected nil", err)
+	}
+	resp.Body.Close()
+	if gotAuth != "" {
+		t.Errorf("expected the token not to be sent to other hosts, got %q", gotAuth)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/Masterminds/semver"
 	kpkgerr "github.com/spachava753/kpkg/pkg/error"
 	"github.com/spachava753/kpkg/pkg/tool"
+	"path"
 	"path/filepath"
 	"strings"
 )
@@ -59,12 +60,25 @@ func (l goreleaserTool) MakeUrl(version string) (string, error) {
 	return url + ".tar.gz", nil
 }
 
+func (l goreleaserTool) ChecksumUrl(version string) (string, string, error) {
+	url, err := l.MakeUrl(version)
+	if err != nil {
+		return "", "", err
+	}
+	version = semver.MustParse(version).String()
+	return fmt.Sprintf(
+		"%sv%s/checksums.txt", l.MakeReleaseUrl(), version,
+	), path.Base(url), nil
+}
+
 func MakeBinary(os, arch string) tool.Binary {
 	return goreleaserTool{
 		arch: arch,
 		os:   os,
 		GithubReleaseTool: tool.MakeGithubReleaseTool(
 			"goreleaser", "goreleaser",
+		).WithVerifiers(
+			tool.MakeCosignVerifier(".sig", tool.VerifyIfPresent),
 		),
 	}
 }
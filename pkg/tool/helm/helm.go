@@ -1,8 +1,10 @@
 package helm
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -77,7 +79,11 @@ func (l helmTool) MakeUrl(version string) (string, error) {
 }
 
 func (l helmTool) Versions(max uint) ([]string, error) {
-	versions, err := l.GithubReleaseTool.Versions(max)
+	return l.VersionsContext(context.Background(), max)
+}
+
+func (l helmTool) VersionsContext(ctx context.Context, max uint) ([]string, error) {
+	versions, err := l.GithubReleaseTool.VersionsContext(ctx, max)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +104,14 @@ func (l helmTool) Versions(max uint) ([]string, error) {
 	return versions, nil
 }
 
+func (l helmTool) ChecksumUrl(version string) (string, string, error) {
+	url, err := l.MakeUrl(version)
+	if err != nil {
+		return "", "", err
+	}
+	return url + ".sha256sum", path.Base(url), nil
+}
+
 func MakeBinary(os, arch string) tool.Binary {
 	return helmTool{
 		arch:              arch,
@@ -6,9 +6,13 @@ import (
 	kpkgerr "github.com/spachava753/kpkg/pkg/error"
 	"github.com/spachava753/kpkg/pkg/tool"
 	"os"
+	"path"
 	"path/filepath"
 )
 
+// lastReleaseWithoutChecksums is the last release of kpkg that did not publish a checksums.txt
+const lastReleaseWithoutChecksums = "0.4.3"
+
 type kpkgTool struct {
 	arch,
 	os string
@@ -83,6 +87,20 @@ func (l kpkgTool) MakeUrl(version string) (string, error) {
 	return url, nil
 }
 
+func (l kpkgTool) ChecksumUrl(version string) (string, string, error) {
+	url, err := l.MakeUrl(version)
+	if err != nil {
+		return "", "", err
+	}
+	v := semver.MustParse(version)
+	if !v.GreaterThan(semver.MustParse(lastReleaseWithoutChecksums)) {
+		return "", "", &kpkgerr.NoChecksumErr{Binary: l.Name(), Version: v.String()}
+	}
+	return fmt.Sprintf(
+		"%s%s/checksums.txt", l.MakeReleaseUrl(), v.String(),
+	), path.Base(url), nil
+}
+
 func MakeBinary(os, arch string) tool.Binary {
 	return kpkgTool{
 		arch:              arch,
@@ -0,0 +1,48 @@
+package kpkg
+
+import (
+	"errors"
+	"testing"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+func TestKpkgTool_ChecksumUrl(t *testing.T) {
+	tests := []struct {
+		name         string
+		version      string
+		wantUrl      string
+		wantArtifact string
+		wantNoSum    bool
+	}{
+		{
+			name:         "release with checksums",
+			version:      "0.5.0",
+			wantUrl:      "https://github.com/spachava753/kpkg/releases/download/0.5.0/checksums.txt",
+			wantArtifact: "kpkg_linux_amd64.zip",
+		},
+		{
+			name:      "release from before checksums",
+			version:   "0.4.3",
+			wantNoSum: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := MakeBinary("linux", "amd64").(kpkgTool)
+			url, artifact, err := c.ChecksumUrl(tt.version)
+			var noSumErr *kpkgerr.NoChecksumErr
+			if errors.As(err, &noSumErr) != tt.wantNoSum {
+				t.Fatalf("ChecksumUrl() error = %v, wantNoSum %v", err, tt.wantNoSum)
+			}
+			if !tt.wantNoSum && err != nil {
+				t.Fatalf("ChecksumUrl() error = %v", err)
+			}
+			if url != tt.wantUrl || artifact != tt.wantArtifact {
+				t.Errorf(
+					"ChecksumUrl() got = %s, %s, want %s, %s", url, artifact, tt.wantUrl, tt.wantArtifact,
+				)
+			}
+		})
+	}
+}
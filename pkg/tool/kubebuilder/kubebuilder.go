@@ -1,6 +1,7 @@
 package kubebuilder
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -126,7 +127,11 @@ func (l kubeBuilderTool) MakeUrl(version string) (string, error) {
 }
 
 func (l kubeBuilderTool) Versions(max uint) ([]string, error) {
-	versions, err := l.GithubReleaseTool.Versions(max)
+	return l.VersionsContext(context.Background(), max)
+}
+
+func (l kubeBuilderTool) VersionsContext(ctx context.Context, max uint) ([]string, error) {
+	versions, err := l.GithubReleaseTool.VersionsContext(ctx, max)
 	if err != nil {
 		return nil, err
 	}
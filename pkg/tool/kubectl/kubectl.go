@@ -67,10 +67,14 @@ func (l kubectlTool) MakeUrl(version string) (string, error) {
 }
 
 func (l kubectlTool) Versions(max uint) ([]string, error) {
-	client := github.NewClient(nil)
+	return l.VersionsContext(context.Background(), max)
+}
+
+func (l kubectlTool) VersionsContext(ctx context.Context, max uint) ([]string, error) {
+	client := tool.GithubClient()
 	var resp *github.Response
 	tags, resp, err := client.Repositories.ListTags(
-		context.Background(), owner, repo, nil,
+		ctx, owner, repo, nil,
 	)
 	if err != nil {
 		return nil, err
@@ -78,7 +82,7 @@ func (l kubectlTool) Versions(max uint) ([]string, error) {
 	var r []*github.RepositoryTag
 	for resp != nil && resp.NextPage != resp.LastPage && uint(len(tags)) < max {
 		r, resp, err = client.Repositories.ListTags(
-			context.Background(), owner, repo, &github.ListOptions{
+			ctx, owner, repo, &github.ListOptions{
 				Page:    resp.NextPage,
 				PerPage: int(max) - len(tags),
 			},
@@ -62,10 +62,14 @@ func (l kubesealTool) MakeUrl(version string) (string, error) {
 }
 
 func (l kubesealTool) Versions(max uint) ([]string, error) {
-	client := github.NewClient(nil)
+	return l.VersionsContext(context.Background(), max)
+}
+
+func (l kubesealTool) VersionsContext(ctx context.Context, max uint) ([]string, error) {
+	client := tool.GithubClient()
 	var resp *github.Response
 	releases, resp, err := client.Repositories.ListReleases(
-		context.Background(), "bitnami-labs", "sealed-secrets", nil,
+		ctx, "bitnami-labs", "sealed-secrets", nil,
 	)
 	if err != nil {
 		return nil, err
@@ -73,7 +77,7 @@ func (l kubesealTool) Versions(max uint) ([]string, error) {
 	var r []*github.RepositoryRelease
 	for resp != nil && resp.NextPage != resp.LastPage && len(releases) < int(max) {
 		r, resp, err = client.Repositories.ListReleases(
-			context.Background(), "bitnami-labs", "sealed-secrets",
+			ctx, "bitnami-labs", "sealed-secrets",
 			&github.ListOptions{
 				Page:    resp.NextPage,
 				PerPage: 20 - len(releases),
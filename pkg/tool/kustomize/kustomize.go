@@ -60,10 +60,14 @@ func (l kustomizeTool) MakeUrl(version string) (string, error) {
 }
 
 func (l kustomizeTool) Versions(max uint) ([]string, error) {
-	client := github.NewClient(nil)
+	return l.VersionsContext(context.Background(), max)
+}
+
+func (l kustomizeTool) VersionsContext(ctx context.Context, max uint) ([]string, error) {
+	client := tool.GithubClient()
 	var resp *github.Response
 	releases, resp, err := client.Repositories.ListReleases(
-		context.Background(), "kubernetes-sigs", "kustomize", nil,
+		ctx, "kubernetes-sigs", "kustomize", nil,
 	)
 	if err != nil {
 		return nil, err
@@ -71,7 +75,7 @@ func (l kustomizeTool) Versions(max uint) ([]string, error) {
 	var r []*github.RepositoryRelease
 	for resp != nil && resp.NextPage != resp.LastPage && len(releases) < int(max) {
 		r, resp, err = client.Repositories.ListReleases(
-			context.Background(), "kubernetes-sigs", "kustomize",
+			ctx, "kubernetes-sigs", "kustomize",
 			&github.ListOptions{
 				Page:    resp.NextPage,
 				PerPage: int(max) - len(releases),
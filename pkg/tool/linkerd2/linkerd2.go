@@ -72,11 +72,15 @@ func (l linkerd2Tool) MakeUrl(version string) (string, error) {
 }
 
 func (l linkerd2Tool) Versions(max uint) ([]string, error) {
+	return l.VersionsContext(context.Background(), max)
+}
+
+func (l linkerd2Tool) VersionsContext(ctx context.Context, max uint) ([]string, error) {
 	// TODO: fix function to use max arg
-	client := github.NewClient(nil)
+	client := tool.GithubClient()
 	var resp *github.Response
 	releases, resp, err := client.Repositories.ListReleases(
-		context.Background(), "linkerd", "linkerd2", nil,
+		ctx, "linkerd", "linkerd2", nil,
 	)
 	if err != nil {
 		return nil, err
@@ -84,7 +88,7 @@ func (l linkerd2Tool) Versions(max uint) ([]string, error) {
 	var r []*github.RepositoryRelease
 	for resp != nil && resp.NextPage != resp.LastPage {
 		r, resp, err = client.Repositories.ListReleases(
-			context.Background(), "linkerd", "linkerd2", &github.ListOptions{
+			ctx, "linkerd", "linkerd2", &github.ListOptions{
 				Page:    resp.NextPage,
 				PerPage: 100,
 			},
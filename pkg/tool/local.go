@@ -0,0 +1,89 @@
+package tool
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// localArtifactPaths returns the dir of local artifacts, and the path of the artifact to install
+// from it. fromFile is either the path of the artifact, or of a dir holding an artifact named
+// like the last element of artifactUrl
+func localArtifactPaths(fromFile, artifactUrl string) (dir, artifact string, err error) {
+	fromFile, err = filepath.Abs(fromFile)
+	if err != nil {
+		return "", "", err
+	}
+	info, err := os.Stat(fromFile)
+	if err != nil {
+		return "", "", err
+	}
+	if !info.IsDir() {
+		return filepath.Dir(fromFile), fromFile, nil
+	}
+
+	name, err := urlFileName(artifactUrl)
+	if err != nil {
+		return "", "", err
+	}
+	artifact = filepath.Join(fromFile, name)
+	if _, err := os.Stat(artifact); err != nil {
+		return "", "", fmt.Errorf(
+			"could not find artifact %s in %s: %w", name, fromFile, err,
+		)
+	}
+	return fromFile, artifact, nil
+}
+
+// hasLocalChecksum checks if the checksum file of the version is in the dir of local artifacts
+func hasLocalChecksum(c Checksummer, version, dir string) bool {
+	checksumUrl, _, err := c.ChecksumUrl(version)
+	if err != nil {
+		// let fetching the checksum report the error
+		return true
+	}
+	name, err := urlFileName(checksumUrl)
+	if err != nil {
+		return true
+	}
+	_, err = os.Stat(filepath.Join(dir, name))
+	return err == nil
+}
+
+// copyArtifact copies a local artifact into dir, so that it can be unpacked and removed
+// like a downloaded artifact, without touching the original
+func copyArtifact(src, dir string) (p string, err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	p = filepath.Join(dir, filepath.Base(src))
+	out, err := os.Create(p)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if e := out.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+	if _, err := io.Copy(out, in); err != nil {
+		return "", err
+	}
+	return p, nil
+}
+
+// urlFileName returns the last element of the path of the url, which is the name
+// a file downloaded from the url is saved as
+func urlFileName(u string) (string, error) {
+	parsedUrl, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	return path.Base(parsedUrl.Path), nil
+}
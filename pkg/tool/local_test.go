@@ -0,0 +1,153 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+// offlineBinary fails to list its versions, like any binary would without network access
+type offlineBinary struct {
+	testBinary
+}
+
+func (o offlineBinary) Versions(_ uint) ([]string, error) {
+	return nil, errors.New("no network access")
+}
+
+// offlineChecksumBinary publishes a checksum file next to its artifacts
+type offlineChecksumBinary struct {
+	offlineBinary
+}
+
+func (o offlineChecksumBinary) ChecksumUrl(version string) (string, string, error) {
+	return "https://some.url/SHA256SUMS", "a-" + version, nil
+}
+
+func TestInstall_FromFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       Binary
+		version string
+		// files are written to the dir of local artifacts
+		files map[string]string
+		// fromFile is the file or dir in the dir of local artifacts to install from
+		fromFile     string
+		wantErr      bool
+		wantSumError bool
+	}{
+		{
+			name:     "artifact",
+			b:        offlineBinary{},
+			version:  "1.0.0",
+			files:    map[string]string{"downloaded-a": "hello"},
+			fromFile: "downloaded-a",
+		},
+		{
+			name:     "dir of artifacts",
+			b:        offlineBinary{},
+			version:  "1.0.0",
+			files:    map[string]string{"a-1.0.0": "hello", "a-2.0.0": "other"},
+			fromFile: ".",
+		},
+		{
+			name:     "dir missing the artifact",
+			b:        offlineBinary{},
+			version:  "3.0.0",
+			files:    map[string]string{"a-1.0.0": "hello"},
+			fromFile: ".",
+			wantErr:  true,
+		},
+		{
+			name:     "latest version",
+			b:        offlineBinary{},
+			version:  "latest",
+			files:    map[string]string{"a-1.0.0": "hello"},
+			fromFile: ".",
+			wantErr:  true,
+		},
+		{
+			name:    "matching checksum",
+			b:       offlineChecksumBinary{},
+			version: "1.0.0",
+			files: map[string]string{
+				"a-1.0.0": "hello", "SHA256SUMS": helloSha256 + "  a-1.0.0\n",
+			},
+			fromFile: ".",
+		},
+		{
+			name:    "mismatched checksum",
+			b:       offlineChecksumBinary{},
+			version: "1.0.0",
+			files: map[string]string{
+				"a-1.0.0": "hello", "SHA256SUMS": "abc  a-1.0.0\n",
+			},
+			fromFile:     ".",
+			wantErr:      true,
+			wantSumError: true,
+		},
+		{
+			name:     "missing checksum",
+			b:        offlineChecksumBinary{},
+			version:  "1.0.0",
+			files:    map[string]string{"a-1.0.0": "hello"},
+			fromFile: ".",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := config.CreatePath(t.TempDir())
+			if err != nil {
+				t.Fatalf("could not create root: %s", err)
+			}
+			dir := t.TempDir()
+			for name, contents := range tt.files {
+				if err := ioutil.WriteFile(
+					filepath.Join(dir, name), []byte(contents), os.ModePerm,
+				); err != nil {
+					t.Fatalf("could not write local artifact: %s", err)
+				}
+			}
+
+			binaryPath, err := Install(
+				context.Background(), root, tt.version, tt.b,
+				testContentsFileFetcher{dir: t.TempDir(), contents: "downloaded"},
+				download.MakeLocalFileFetcher(),
+				InstallOptions{FromFile: filepath.Join(dir, tt.fromFile)},
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Install() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var checksumErr *kpkgerr.ChecksumErr
+			if errors.As(err, &checksumErr) != tt.wantSumError {
+				t.Errorf("Install() error = %v, wantSumError %v", err, tt.wantSumError)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			contents, err := ioutil.ReadFile(binaryPath)
+			if err != nil {
+				t.Fatalf("could not read binary: %s", err)
+			}
+			if string(contents) != "hello" {
+				t.Errorf("expected the local artifact to be installed, got contents %s", contents)
+			}
+			// the local artifacts should be left untouched
+			entries, err := ioutil.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("could not read dir of local artifacts: %s", err)
+			}
+			if len(entries) != len(tt.files) {
+				t.Errorf("expected %d local artifacts, found %d", len(tt.files), len(entries))
+			}
+		})
+	}
+}
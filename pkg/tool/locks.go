@@ -0,0 +1,68 @@
+package tool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spachava753/kpkg/pkg/lock"
+)
+
+// LockTimeout is how long to wait for other kpkg processes to release their locks on the root
+var LockTimeout = time.Minute
+
+// locksDirName is the folder in the kpkg root that holds the lock files
+const locksDirName = ".locks"
+
+// rootLockName is the lock file guarding the kpkg root as a whole
+const rootLockName = "root.lock"
+
+// lockBinary takes the locks needed to modify the installs of a binary.
+// The root is locked shared, so that operations on different binaries can run
+// concurrently, while the binary itself is locked exclusively.
+// Waiting for the locks stops early if ctx is done. The returned func releases the locks
+func lockBinary(ctx context.Context, basePath, binary string) (func() error, error) {
+	if _, err := os.Stat(basePath); err != nil {
+		return nil, err
+	}
+	locksPath := filepath.Join(basePath, locksDirName)
+
+	rootLock, err := lock.Acquire(
+		ctx, filepath.Join(locksPath, rootLockName), false, LockTimeout,
+	)
+	if err != nil {
+		return nil, err
+	}
+	binaryLock, err := lock.Acquire(
+		ctx, filepath.Join(locksPath, binary+".lock"), true, LockTimeout,
+	)
+	if err != nil {
+		_ = rootLock.Release()
+		return nil, err
+	}
+
+	return func() error {
+		err := binaryLock.Release()
+		if e := rootLock.Release(); e != nil && err == nil {
+			err = e
+		}
+		return err
+	}, nil
+}
+
+// LockRoot takes an exclusive lock on the kpkg root, for structural operations that
+// touch the installs of every binary. It waits for all operations on binaries to finish.
+// Waiting for the lock stops early if ctx is done. The returned func releases the lock
+func LockRoot(ctx context.Context, basePath string) (func() error, error) {
+	if _, err := os.Stat(basePath); err != nil {
+		return nil, err
+	}
+	rootLock, err := lock.Acquire(
+		ctx, filepath.Join(basePath, locksDirName, rootLockName), true, LockTimeout,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return rootLock.Release, nil
+}
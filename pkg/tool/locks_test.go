@@ -0,0 +1,55 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+func Test_lockBinary(t *testing.T) {
+	defer func(timeout time.Duration) {
+		LockTimeout = timeout
+	}(LockTimeout)
+	LockTimeout = 100 * time.Millisecond
+
+	root := t.TempDir()
+	unlockA, err := lockBinary(context.Background(), root, "a")
+	if err != nil {
+		t.Fatalf("lockBinary() error = %v, expected nil", err)
+	}
+
+	// other binaries can still be locked
+	unlockB, err := lockBinary(context.Background(), root, "b")
+	if err != nil {
+		t.Errorf("lockBinary() error = %v, expected nil", err)
+	}
+	if err := unlockB(); err != nil {
+		t.Errorf("unlock error = %v, expected nil", err)
+	}
+
+	// the same binary, or the root as a whole, can not
+	var timeoutErr *kpkgerr.LockTimeoutErr
+	if _, err := lockBinary(context.Background(), root, "a"); !errors.As(err, &timeoutErr) {
+		t.Errorf("lockBinary() error = %v, expected lock timeout", err)
+	}
+	if err := Purge(context.Background(), root, "a"); !errors.As(err, &timeoutErr) {
+		t.Errorf("Purge() error = %v, expected lock timeout", err)
+	}
+	if _, err := LockRoot(context.Background(), root); !errors.As(err, &timeoutErr) {
+		t.Errorf("LockRoot() error = %v, expected lock timeout", err)
+	}
+
+	if err := unlockA(); err != nil {
+		t.Errorf("unlock error = %v, expected nil", err)
+	}
+	unlockRoot, err := LockRoot(context.Background(), root)
+	if err != nil {
+		t.Fatalf("LockRoot() error = %v, expected nil", err)
+	}
+	if err := unlockRoot(); err != nil {
+		t.Errorf("unlock error = %v, expected nil", err)
+	}
+}
@@ -66,11 +66,15 @@ func (l mcTool) MakeUrl(version string) (string, error) {
 }
 
 func (l mcTool) Versions(max uint) ([]string, error) {
+	return l.VersionsContext(context.Background(), max)
+}
+
+func (l mcTool) VersionsContext(ctx context.Context, max uint) ([]string, error) {
 	// minio client doesn't use semantic versioning
-	client := github.NewClient(nil)
+	client := tool.GithubClient()
 	var resp *github.Response
 	releases, resp, err := client.Repositories.ListReleases(
-		context.Background(), "minio", "minio", nil,
+		ctx, "minio", "minio", nil,
 	)
 	if err != nil {
 		return nil, err
@@ -78,7 +82,7 @@ func (l mcTool) Versions(max uint) ([]string, error) {
 	var r []*github.RepositoryRelease
 	for resp != nil && resp.NextPage != resp.LastPage && len(releases) < int(max) {
 		r, resp, err = client.Repositories.ListReleases(
-			context.Background(), "minio", "minio", &github.ListOptions{
+			ctx, "minio", "minio", &github.ListOptions{
 				Page:    resp.NextPage,
 				PerPage: int(max) - len(releases),
 			},
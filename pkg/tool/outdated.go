@@ -0,0 +1,123 @@
+package tool
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// MaxUpdateVersions bounds how many versions VersionsSince lists to find the updates of a binary,
+// and how many are listed to find a version that matches a constraint
+const MaxUpdateVersions = 1000
+
+// Updates are the versions of a binary newer than its current version. A kind of update that
+// is not available is empty
+type Updates struct {
+	Binary  string `json:"binary"`
+	Current string `json:"current"`
+	// Patch is the latest version with the same major and minor version as the current version
+	Patch string `json:"patch,omitempty"`
+	// Minor is the latest version with the same major version as the current version
+	Minor string `json:"minor,omitempty"`
+	// Latest is the latest version
+	Latest string `json:"latest,omitempty"`
+}
+
+// Outdated checks if there is a newer version than the current version
+func (u Updates) Outdated() bool {
+	return u.Latest != ""
+}
+
+// FindUpdates compares the current version of the binary to its versions, as listed by
+// Binary.Versions. Versions are compared as semver, and prereleases are skipped, unless the
+// current version is a prerelease too. Binaries that don't use semver, like mc, are only checked
+// for a latest version, which is the first version listed. It is newer if it sorts after the
+// current version, since those binaries version their releases by date
+func FindUpdates(binary, current string, versions []string) Updates {
+	u := Updates{Binary: binary, Current: current}
+	cv, err := parseUpdateVersion(current)
+	if err != nil {
+		if len(versions) != 0 && versions[0] > current {
+			u.Latest = versions[0]
+		}
+		return u
+	}
+
+	var patch, minor, latest *semver.Version
+	for _, s := range versions {
+		v, err := parseUpdateVersion(s)
+		if err != nil || !v.GreaterThan(cv) || v.Prerelease() != "" && cv.Prerelease() == "" {
+			continue
+		}
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+		}
+		if v.Major() != cv.Major() {
+			continue
+		}
+		if minor == nil || v.GreaterThan(minor) {
+			minor = v
+		}
+		if v.Minor() == cv.Minor() && (patch == nil || v.GreaterThan(patch)) {
+			patch = v
+		}
+	}
+	for _, p := range []struct {
+		v *semver.Version
+		s *string
+	}{{patch, &u.Patch}, {minor, &u.Minor}, {latest, &u.Latest}} {
+		if p.v != nil {
+			*p.s = p.v.String()
+		}
+	}
+	return u
+}
+
+// VersionsSince lists at least the max latest versions of the binary, and more until they reach
+// back to the current version, so that every update of it is listed. Versions are listed by the
+// date of their release, so a patch of an old minor version can be released after many newer
+// versions, and be missing from the latest versions. Listing stops once every version is listed,
+// or once MaxUpdateVersions versions are, so updates of an older version may be missed
+func VersionsSince(
+	ctx context.Context, source VersionSource, b Binary, current string, max uint,
+) ([]string, error) {
+	if max == 0 {
+		max = 1
+	}
+	for {
+		versions, err := source.Versions(ctx, b, max)
+		if err != nil {
+			return nil, err
+		}
+		if uint(len(versions)) < max || max >= MaxUpdateVersions || listed(versions, current) {
+			return versions, nil
+		}
+		if max *= 2; max > MaxUpdateVersions {
+			max = MaxUpdateVersions
+		}
+	}
+}
+
+// listed checks if the version is one of the versions, comparing them as semver if they are
+func listed(versions []string, version string) bool {
+	v, err := parseUpdateVersion(version)
+	for _, s := range versions {
+		if s == version {
+			return true
+		}
+		if err != nil {
+			continue
+		}
+		if other, err := parseUpdateVersion(s); err == nil && other.Equal(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseUpdateVersion parses a version as semver. The channel of linkerd2 releases is dropped,
+// so that stable-2.10.2 is 2.10.2
+func parseUpdateVersion(version string) (*semver.Version, error) {
+	return semver.NewVersion(strings.TrimPrefix(version, "stable-"))
+}
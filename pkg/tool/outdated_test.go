@@ -0,0 +1,142 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFindUpdates(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		versions []string
+		want     Updates
+	}{
+		{
+			name:     "patch, minor and major updates",
+			current:  "1.19.4",
+			versions: []string{"2.0.1", "2.0.0", "1.21.0", "1.20.2", "1.19.10", "1.19.4", "1.19.2"},
+			want:     Updates{Current: "1.19.4", Patch: "1.19.10", Minor: "1.21.0", Latest: "2.0.1"},
+		},
+		{
+			name:     "up to date",
+			current:  "1.21.0",
+			versions: []string{"1.21.0", "1.20.2"},
+			want:     Updates{Current: "1.21.0"},
+		},
+		{
+			name:     "prereleases are skipped",
+			current:  "1.20.0",
+			versions: []string{"1.21.0-rc.1", "1.20.1"},
+			want:     Updates{Current: "1.20.0", Patch: "1.20.1", Minor: "1.20.1", Latest: "1.20.1"},
+		},
+		{
+			name:     "unsorted versions of a channel",
+			current:  "2.9.1",
+			versions: []string{"stable-2.9.4", "stable-2.10.2", "stable-2.10.1"},
+			want:     Updates{Current: "2.9.1", Patch: "2.9.4", Minor: "2.10.2", Latest: "2.10.2"},
+		},
+		{
+			name:    "versions by date",
+			current: "RELEASE.2021-05-18T16-03-35Z",
+			versions: []string{
+				"RELEASE.2021-06-13T17-48-22Z", "RELEASE.2021-05-18T16-03-35Z",
+			},
+			want: Updates{Current: "RELEASE.2021-05-18T16-03-35Z", Latest: "RELEASE.2021-06-13T17-48-22Z"},
+		},
+		{
+			name:     "latest version by date is installed",
+			current:  "RELEASE.2021-06-13T17-48-22Z",
+			versions: []string{"RELEASE.2021-06-13T17-48-22Z"},
+			want:     Updates{Current: "RELEASE.2021-06-13T17-48-22Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.Binary = "a"
+			got := FindUpdates("a", tt.current, tt.versions)
+			if got != tt.want {
+				t.Errorf("FindUpdates() got = %+v, want %+v", got, tt.want)
+			}
+			if got.Outdated() != (tt.want.Latest != "") {
+				t.Errorf("Outdated() got = %v", got.Outdated())
+			}
+		})
+	}
+}
+
+// pagedVersions lists fixed versions, newest first, and records the max of every listing
+type pagedVersions struct {
+	versions []string
+	maxes    []uint
+}
+
+func (p *pagedVersions) Versions(_ context.Context, _ Binary, max uint) ([]string, error) {
+	p.maxes = append(p.maxes, max)
+	if uint(len(p.versions)) > max {
+		return p.versions[:max], nil
+	}
+	return p.versions, nil
+}
+
+func (p *pagedVersions) Refresh(ctx context.Context, b Binary, max uint) ([]string, error) {
+	return p.Versions(ctx, b, max)
+}
+
+func TestVersionsSince(t *testing.T) {
+	// releases of newer minor versions push the patches of 1.19 out of the latest versions
+	var versions []string
+	for i := 30; i > 0; i-- {
+		versions = append(versions, fmt.Sprintf("1.22.%d", i))
+		if i == 20 {
+			versions = append(versions, "1.19.16")
+		}
+	}
+	versions = append(versions, "1.19.4", "1.19.3")
+
+	tests := []struct {
+		name      string
+		current   string
+		max       uint
+		wantMaxes []uint
+		wantPatch string
+	}{
+		{
+			name:      "latest versions reach the current version",
+			current:   "1.22.25",
+			max:       10,
+			wantMaxes: []uint{10},
+			wantPatch: "1.22.30",
+		},
+		{
+			name:      "more versions are listed until the current version",
+			current:   "1.19.4",
+			max:       10,
+			wantMaxes: []uint{10, 20, 40},
+			wantPatch: "1.19.16",
+		},
+		{
+			name:      "every version is listed",
+			current:   "1.18.0",
+			max:       20,
+			wantMaxes: []uint{20, 40},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &pagedVersions{versions: versions}
+			got, err := VersionsSince(context.Background(), source, testBinary{}, tt.current, tt.max)
+			if err != nil {
+				t.Fatalf("VersionsSince() error = %v", err)
+			}
+			if !reflect.DeepEqual(source.maxes, tt.wantMaxes) {
+				t.Errorf("VersionsSince() listed %v versions, want %v", source.maxes, tt.wantMaxes)
+			}
+			if u := FindUpdates("a", tt.current, got); u.Patch != tt.wantPatch {
+				t.Errorf("FindUpdates() patch = %s, want %s", u.Patch, tt.wantPatch)
+			}
+		})
+	}
+}
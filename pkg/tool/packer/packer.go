@@ -3,6 +3,7 @@ package packer
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/Masterminds/semver"
@@ -15,6 +16,7 @@ type packerTool struct {
 	arch,
 	os string
 	tool.GithubReleaseTool
+	tool.HashicorpReleaseTool
 }
 
 func (l packerTool) Extract(artifactPath, _ string) (string, error) {
@@ -94,10 +96,20 @@ func (l packerTool) MakeUrl(version string) (string, error) {
 	return url, nil
 }
 
+func (l packerTool) ChecksumUrl(version string) (string, string, error) {
+	url, err := l.MakeUrl(version)
+	if err != nil {
+		return "", "", err
+	}
+	sumsUrl, err := l.MakeSumsUrl(version)
+	return sumsUrl, path.Base(url), err
+}
+
 func MakeBinary(os, arch string) tool.Binary {
 	return packerTool{
-		arch:              arch,
-		os:                os,
-		GithubReleaseTool: tool.MakeGithubReleaseTool("hashicorp", "packer"),
+		arch:                 arch,
+		os:                   os,
+		GithubReleaseTool:    tool.MakeGithubReleaseTool("hashicorp", "packer"),
+		HashicorpReleaseTool: tool.MakeHashicorpReleaseTool("packer"),
 	}
 }
@@ -0,0 +1,89 @@
+package tool
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// receiptFileName is the name of the receipt file written next to an installed binary
+const receiptFileName = "receipt.json"
+
+// Receipt records where an installed version of a binary came from
+type Receipt struct {
+	Binary         string    `json:"binary"`
+	Version        string    `json:"version"`
+	Url            string    `json:"url"`
+	ArtifactSha256 string    `json:"artifactSha256"`
+	BinarySha256   string    `json:"binarySha256"`
+	Size           int64     `json:"size"`
+	InstalledAt    time.Time `json:"installedAt"`
+	KpkgVersion    string    `json:"kpkgVersion"`
+	Os             string    `json:"os"`
+	Arch           string    `json:"arch"`
+	Force          bool      `json:"force"`
+}
+
+// writeReceipt writes the receipt into the version dir of an install
+func writeReceipt(versionPath string, r Receipt) error {
+	contents, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(
+		filepath.Join(versionPath, receiptFileName), contents, 0644,
+	)
+}
+
+// ReadReceipt reads the receipt of an installed version of a binary.
+// Versions installed before kpkg recorded receipts don't have one,
+// in which case a nil receipt and a nil error are returned
+func ReadReceipt(basePath, binary, version string) (*Receipt, error) {
+	contents, err := ioutil.ReadFile(
+		filepath.Join(basePath, binary, version, receiptFileName),
+	)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var r Receipt
+	if err := json.Unmarshal(contents, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// makeReceipt builds the receipt of an install from the downloaded artifact and the staged binary
+func makeReceipt(
+	binary, version, url, artifactPath, binaryPath string, opts InstallOptions,
+) (Receipt, error) {
+	artifactSha256, err := fileSha256(artifactPath)
+	if err != nil {
+		return Receipt{}, err
+	}
+	binarySha256, err := fileSha256(binaryPath)
+	if err != nil {
+		return Receipt{}, err
+	}
+	info, err := os.Stat(binaryPath)
+	if err != nil {
+		return Receipt{}, err
+	}
+	return Receipt{
+		Binary:         binary,
+		Version:        version,
+		Url:            url,
+		ArtifactSha256: artifactSha256,
+		BinarySha256:   binarySha256,
+		Size:           info.Size(),
+		InstalledAt:    time.Now().UTC(),
+		KpkgVersion:    opts.KpkgVersion,
+		Os:             opts.Os,
+		Arch:           opts.Arch,
+		Force:          opts.Force,
+	}, nil
+}
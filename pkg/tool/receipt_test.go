@@ -0,0 +1,69 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/spachava753/kpkg/pkg/config"
+)
+
+func TestReadReceipt(t *testing.T) {
+	root, err := config.CreatePath(t.TempDir())
+	if err != nil {
+		t.Fatalf("could not create root: %s", err)
+	}
+
+	// install a version with a receipt
+	if _, err := installTestBinary(t, root, "hello", testBinary{}, false); err != nil {
+		t.Fatalf("Install() error = %v, expected nil", err)
+	}
+
+	// fake a version installed before receipts were recorded
+	if err := os.MkdirAll(filepath.Join(root, "a", "0.9.0"), 0755); err != nil {
+		t.Fatalf("could not create dir: %s", err)
+	}
+	if _, err := os.Create(filepath.Join(root, "a", "0.9.0", "a")); err != nil {
+		t.Fatalf("could not create binary: %s", err)
+	}
+
+	versions, err := ListToolVersionsInstalled(root, "a")
+	if err != nil {
+		t.Fatalf("ListToolVersionsInstalled() error = %v, expected nil", err)
+	}
+	if !reflect.DeepEqual(versions, []string{"0.9.0", "1.0.0"}) {
+		t.Errorf("ListToolVersionsInstalled() got = %v", versions)
+	}
+
+	r, err := ReadReceipt(root, "a", "1.0.0")
+	if err != nil {
+		t.Fatalf("ReadReceipt() error = %v, expected nil", err)
+	}
+	if r == nil {
+		t.Fatalf("ReadReceipt() expected a receipt, got nil")
+	}
+	if r.Url != "https://some.url/a-1.0.0" {
+		t.Errorf("ReadReceipt() got url = %s", r.Url)
+	}
+	if r.ArtifactSha256 != helloSha256 || r.BinarySha256 != helloSha256 {
+		t.Errorf(
+			"ReadReceipt() got digests = %s, %s, want %s", r.ArtifactSha256,
+			r.BinarySha256, helloSha256,
+		)
+	}
+	if r.Size != int64(len("hello")) {
+		t.Errorf("ReadReceipt() got size = %d", r.Size)
+	}
+	if r.InstalledAt.IsZero() {
+		t.Errorf("ReadReceipt() expected install time to be set")
+	}
+
+	r, err = ReadReceipt(root, "a", "0.9.0")
+	if err != nil {
+		t.Errorf("ReadReceipt() error = %v, expected nil", err)
+	}
+	if r != nil {
+		t.Errorf("ReadReceipt() expected nil receipt, got %v", r)
+	}
+}
@@ -0,0 +1,201 @@
+package tool
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver"
+
+	"github.com/spachava753/kpkg/pkg/pin"
+)
+
+// linksDirName is the folder in the kpkg root that holds the links to the linked versions of
+// binaries while shims are enabled, since the bin folder holds the shims instead
+const linksDirName = "links"
+
+// ShimsEnabled checks if the bin folder of the kpkg root holds shims. A shim is a link to the
+// kpkg executable, which runs the version of the binary pinned for the current dir
+func ShimsEnabled(basePath string) bool {
+	info, err := os.Stat(filepath.Join(basePath, linksDirName))
+	return err == nil && info.IsDir()
+}
+
+// linkDir returns the folder holding the links to the linked versions of binaries
+func linkDir(basePath string) string {
+	if ShimsEnabled(basePath) {
+		return filepath.Join(basePath, linksDirName)
+	}
+	return filepath.Join(basePath, "bin")
+}
+
+// link points the link of the binary to binaryPath, and writes the shim of the binary
+// if shims are enabled
+func link(basePath, stagingPath, binaryPath, binary, shim string) error {
+	if err := linkBinary(basePath, stagingPath, binaryPath, binary); err != nil {
+		return err
+	}
+	if !ShimsEnabled(basePath) {
+		return nil
+	}
+	return writeShim(basePath, binary, shim)
+}
+
+// writeShim links the binary in the bin folder to the shim executable. A binary named like the
+// executable, which is kpkg itself, would run itself, so it is linked to its linked version instead
+func writeShim(basePath, binary, shim string) error {
+	if shim == "" {
+		return fmt.Errorf("shims are enabled, but there is no shim executable to link %s to", binary)
+	}
+	target := shim
+	if binary == filepath.Base(shim) {
+		var err error
+		if target, err = os.Readlink(filepath.Join(basePath, linksDirName, binary)); err != nil {
+			return err
+		}
+	}
+	shimPath := filepath.Join(basePath, "bin", binary)
+	if t, err := os.Readlink(shimPath); err == nil && t == target {
+		return nil
+	}
+	tmpShimPath := shimPath + ".shim"
+	_ = os.Remove(tmpShimPath)
+	if err := os.Symlink(target, tmpShimPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpShimPath, shimPath); err != nil {
+		_ = os.Remove(tmpShimPath)
+		return fmt.Errorf("could not write shim at path %s: %w", shimPath, err)
+	}
+	return nil
+}
+
+// EnableShims replaces the links in the bin folder with shims, which link to the shim executable.
+// The links are kept in the links folder, so that the linked versions are still used where no
+// version is pinned
+func EnableShims(ctx context.Context, basePath, shim string) (err error) {
+	unlock, err := LockRoot(ctx, basePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := unlock(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	binPath := filepath.Join(basePath, "bin")
+	linksPath := filepath.Join(basePath, linksDirName)
+	if err := os.MkdirAll(linksPath, os.ModePerm); err != nil {
+		return err
+	}
+	entries, err := ioutil.ReadDir(binPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		name := entry.Name()
+		if target, err := os.Readlink(filepath.Join(binPath, name)); err != nil || target == shim {
+			continue
+		}
+		if err := os.Rename(filepath.Join(binPath, name), filepath.Join(linksPath, name)); err != nil {
+			return err
+		}
+	}
+
+	// shim every link, including the ones of a previous attempt that was cut off
+	links, err := ioutil.ReadDir(linksPath)
+	if err != nil {
+		return err
+	}
+	for _, l := range links {
+		if err := writeShim(basePath, l.Name(), shim); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DisableShims moves the links to the linked versions back into the bin folder, replacing the shims
+func DisableShims(ctx context.Context, basePath string) (err error) {
+	unlock, err := LockRoot(ctx, basePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := unlock(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	linksPath := filepath.Join(basePath, linksDirName)
+	links, err := ioutil.ReadDir(linksPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, l := range links {
+		if err := os.Rename(
+			filepath.Join(linksPath, l.Name()), filepath.Join(basePath, "bin", l.Name()),
+		); err != nil {
+			return err
+		}
+	}
+	return os.Remove(linksPath)
+}
+
+// ShimRoot returns the kpkg root that holds the shim at shimPath in its bin folder, and whether
+// there is one. A shim runs the versions of the root it is in, which is not always the configured
+// root, for example when shims were enabled in a root passed with --root
+func ShimRoot(shimPath string) (string, bool) {
+	binPath := filepath.Dir(shimPath)
+	if filepath.Base(binPath) != "bin" {
+		return "", false
+	}
+	basePath := filepath.Dir(binPath)
+	return basePath, ShimsEnabled(basePath)
+}
+
+// ShimTarget returns the path of the version of the binary that its shim runs in dir. It is the
+// version pinned for dir by the nearest pin file, or else the linked version
+func ShimTarget(basePath, binary, dir string) (string, error) {
+	name := strings.TrimSuffix(binary, ".exe")
+	version, pinPath, err := pin.Find(dir, name)
+	if err != nil {
+		return "", err
+	}
+	if version != "" {
+		// versions are installed in the dirs of their semver form, so v1.20.0 is 1.20.0
+		if v, err := semver.NewVersion(version); err == nil {
+			version = v.String()
+		}
+		installed, err := Installed(basePath, binary, version)
+		if err != nil {
+			return "", err
+		}
+		if !installed {
+			return "", fmt.Errorf(
+				"%s %s is pinned by %s, but not installed. Install it with kpkg get %s %s",
+				name, version, pinPath, name, version,
+			)
+		}
+		return filepath.Join(basePath, binary, version, binary), nil
+	}
+
+	target, err := filepath.EvalSymlinks(filepath.Join(linkDir(basePath), binary))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no version of %s is pinned or linked", name)
+		}
+		return "", err
+	}
+	return target, nil
+}
@@ -0,0 +1,134 @@
+package tool
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/download"
+	"github.com/spachava753/kpkg/pkg/pin"
+)
+
+func TestShims(t *testing.T) {
+	root, err := config.CreatePath(t.TempDir())
+	if err != nil {
+		t.Fatalf("could not create root: %s", err)
+	}
+	shim := filepath.Join(t.TempDir(), "kpkg")
+	install := func(version string) {
+		t.Helper()
+		if _, err := Install(
+			context.Background(), root, version, multiVersionBinary{},
+			testContentsFileFetcher{dir: t.TempDir(), contents: version},
+			download.MakeLocalFileFetcher(), InstallOptions{Max: 20, Shim: shim},
+		); err != nil {
+			t.Fatalf("Install() error = %v", err)
+		}
+	}
+	install("1.0.0")
+
+	if err := EnableShims(context.Background(), root, shim); err != nil {
+		t.Fatalf("EnableShims() error = %v", err)
+	}
+	if !ShimsEnabled(root) {
+		t.Fatalf("ShimsEnabled() got = false after enabling shims")
+	}
+	if target, err := os.Readlink(filepath.Join(root, "bin", "a")); err != nil || target != shim {
+		t.Errorf("bin/a links to %s, %v, want the shim %s", target, err, shim)
+	}
+	// installing while shims are enabled links the version behind the shim
+	install("2.0.0")
+	if v, err := LinkedVersion(root, "a"); err != nil || v != "2.0.0" {
+		t.Errorf("LinkedVersion() = %s, %v, want 2.0.0", v, err)
+	}
+	if target, err := os.Readlink(filepath.Join(root, "bin", "a")); err != nil || target != shim {
+		t.Errorf("bin/a links to %s, %v, want the shim %s", target, err, shim)
+	}
+
+	// the shim runs the linked version, unless a version is pinned
+	dir := t.TempDir()
+	if got, err := ShimTarget(root, "a", dir); err != nil || filepath.Base(filepath.Dir(got)) != "2.0.0" {
+		t.Errorf("ShimTarget() = %s, %v, want the linked version", got, err)
+	}
+	if err := pin.Set(filepath.Join(dir, pin.FileName), "a", "v1.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	nested := filepath.Join(dir, "nested")
+	if err := os.Mkdir(nested, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(root, "a", "1.0.0", "a")
+	if got, err := ShimTarget(root, "a", nested); err != nil || got != want {
+		t.Errorf("ShimTarget() = %s, %v, want %s", got, err, want)
+	}
+	if err := pin.Set(filepath.Join(dir, pin.FileName), "a", "3.0.0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ShimTarget(root, "a", nested); err == nil {
+		t.Errorf("ShimTarget() expected an error for a version that is not installed")
+	}
+
+	if err := DisableShims(context.Background(), root); err != nil {
+		t.Fatalf("DisableShims() error = %v", err)
+	}
+	if ShimsEnabled(root) {
+		t.Errorf("ShimsEnabled() got = true after disabling shims")
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(root, "bin", "a"))
+	if err != nil || string(contents) != "2.0.0" {
+		t.Errorf("bin/a = %s, %v, want the linked version", contents, err)
+	}
+}
+
+func TestShimRoot(t *testing.T) {
+	shim := filepath.Join(t.TempDir(), "kpkg")
+	makeRoot := func(enable bool) string {
+		t.Helper()
+		root, err := config.CreatePath(t.TempDir())
+		if err != nil {
+			t.Fatalf("could not create root: %s", err)
+		}
+		if _, err := Install(
+			context.Background(), root, "1.0.0", testBinary{},
+			testContentsFileFetcher{dir: t.TempDir(), contents: "1.0.0"},
+			download.MakeLocalFileFetcher(), InstallOptions{Max: 20, Shim: shim},
+		); err != nil {
+			t.Fatalf("Install() error = %v", err)
+		}
+		if enable {
+			if err := EnableShims(context.Background(), root, shim); err != nil {
+				t.Fatalf("EnableShims() error = %v", err)
+			}
+		}
+		return root
+	}
+	// shims enabled in a root other than the default one run the versions of that root
+	other := makeRoot(true)
+	got, ok := ShimRoot(filepath.Join(other, "bin", "a"))
+	if !ok || got != other {
+		t.Fatalf("ShimRoot() = %s, %v, want %s", got, ok, other)
+	}
+	want := filepath.Join(other, "a", "1.0.0", "a")
+	if target, err := ShimTarget(got, "a", t.TempDir()); err != nil || target != want {
+		t.Errorf("ShimTarget() = %s, %v, want %s", target, err, want)
+	}
+
+	if got, ok := ShimRoot(filepath.Join(makeRoot(false), "bin", "a")); ok {
+		t.Errorf("ShimRoot() = %s, want no root for a root without shims", got)
+	}
+	if got, ok := ShimRoot(filepath.Join(t.TempDir(), "a")); ok {
+		t.Errorf("ShimRoot() = %s, want no root for a path outside of a bin dir", got)
+	}
+}
+
+// multiVersionBinary is a testBinary with more than one version
+type multiVersionBinary struct {
+	testBinary
+}
+
+func (multiVersionBinary) Versions(_ uint) ([]string, error) {
+	return []string{"2.0.0", "1.0.0"}, nil
+}
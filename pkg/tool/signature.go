@@ -0,0 +1,95 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+// ChecksumSigner is an optional interface that a Checksummer can implement if its checksum
+// file is signed with a detached OpenPGP signature, like the SHA256SUMS.sig files published
+// on releases.hashicorp.com
+type ChecksumSigner interface {
+	// ChecksumSignatureUrl returns the url of the detached signature of the checksum file
+	ChecksumSignatureUrl(version string) (string, error)
+
+	// Keyring returns the bundled public keys trusted to sign the checksum file
+	Keyring() (openpgp.EntityList, error)
+}
+
+// verifySignature fetches the detached signature of the checksum file at checksumPath,
+// and checks it against the keyring. If keyring is nil, the keys bundled with the signer are used
+func verifySignature(
+	ctx context.Context, s ChecksumSigner, version, checksumPath string, keyring openpgp.EntityList,
+	f download.FileFetcher,
+) error {
+	if keyring == nil {
+		var err error
+		keyring, err = s.Keyring()
+		if err != nil {
+			return fmt.Errorf("could not read bundled keyring: %w", err)
+		}
+	}
+
+	url, err := s.ChecksumSignatureUrl(version)
+	if err != nil {
+		return err
+	}
+
+	sigPath, err := f.FetchFile(ctx, url)
+	if err != nil {
+		return fmt.Errorf("could not fetch checksum signature: %w", err)
+	}
+	defer os.Remove(sigPath)
+
+	checksumFile, err := os.Open(checksumPath)
+	if err != nil {
+		return err
+	}
+	defer checksumFile.Close()
+
+	sigFile, err := os.Open(sigPath)
+	if err != nil {
+		return err
+	}
+	defer sigFile.Close()
+
+	if _, err := openpgp.CheckDetachedSignature(
+		keyring, checksumFile, sigFile,
+	); err != nil {
+		return &kpkgerr.SignatureErr{
+			Artifact: filepath.Base(checksumPath),
+			Err:      err,
+		}
+	}
+	return nil
+}
+
+// LoadKeyring reads an OpenPGP keyring from the file at the path.
+// The keyring may either be ascii armored, or in binary form
+func LoadKeyring(p string) (openpgp.EntityList, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	// armored keyrings start with a header like "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+	header, err := r.Peek(5)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if string(header) == "-----" {
+		return openpgp.ReadArmoredKeyRing(r)
+	}
+	return openpgp.ReadKeyRing(r)
+}
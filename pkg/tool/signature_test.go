@@ -0,0 +1,175 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+type testSigner struct {
+	testChecksummer
+	sigUrl  string
+	keyring openpgp.EntityList
+}
+
+func (t testSigner) ChecksumSignatureUrl(_ string) (string, error) {
+	return t.sigUrl, nil
+}
+
+func (t testSigner) Keyring() (openpgp.EntityList, error) {
+	return t.keyring, nil
+}
+
+func makeTestEntity(t *testing.T) *openpgp.Entity {
+	e, err := openpgp.NewEntity("kpkg", "test", "kpkg@example.com", nil)
+	if err != nil {
+		t.Fatalf("could not generate key: %s", err)
+	}
+	return e
+}
+
+func signTestSums(t *testing.T, e *openpgp.Entity, sums string) []byte {
+	var sig bytes.Buffer
+	if err := openpgp.DetachSign(
+		&sig, e, bytes.NewBufferString(sums), nil,
+	); err != nil {
+		t.Fatalf("could not sign sums: %s", err)
+	}
+	return sig.Bytes()
+}
+
+// signedBinary is a testBinary that publishes a signed checksum file
+type signedBinary struct {
+	testBinary
+	testSigner
+}
+
+func TestInstall_ChecksumSignature(t *testing.T) {
+	signingKey := makeTestEntity(t)
+	otherKey := makeTestEntity(t)
+	sums := helloSha256 + "  a-1.0.0\n"
+
+	tests := []struct {
+		name         string
+		servedSums   string
+		sig          []byte
+		bundled      openpgp.EntityList
+		keyring      openpgp.EntityList
+		wantErr      bool
+		wantSigError bool
+	}{
+		{
+			name:       "signed by bundled key",
+			servedSums: sums,
+			sig:        signTestSums(t, signingKey, sums),
+			bundled:    openpgp.EntityList{signingKey},
+		},
+		{
+			name:       "signed by configured key",
+			servedSums: sums,
+			sig:        signTestSums(t, signingKey, sums),
+			bundled:    openpgp.EntityList{otherKey},
+			keyring:    openpgp.EntityList{signingKey},
+		},
+		{
+			name:         "signed by unknown key",
+			servedSums:   sums,
+			sig:          signTestSums(t, otherKey, sums),
+			bundled:      openpgp.EntityList{signingKey},
+			wantErr:      true,
+			wantSigError: true,
+		},
+		{
+			name:         "tampered sums",
+			servedSums:   "abc  a-1.0.0\n",
+			sig:          signTestSums(t, signingKey, sums),
+			bundled:      openpgp.EntityList{signingKey},
+			wantErr:      true,
+			wantSigError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := config.CreatePath(t.TempDir())
+			if err != nil {
+				t.Fatalf("could not create root: %s", err)
+			}
+			b := signedBinary{
+				testSigner: testSigner{
+					testChecksummer: testChecksummer{
+						url:      "https://some.url/SHA256SUMS",
+						artifact: "a-1.0.0",
+					},
+					sigUrl:  "https://some.url/SHA256SUMS.sig",
+					keyring: tt.bundled,
+				},
+			}
+			f := testFilesFileFetcher{
+				files: map[string]string{
+					"SHA256SUMS": tt.servedSums, "SHA256SUMS.sig": string(tt.sig), "a-1.0.0": "hello",
+				},
+				dir: t.TempDir(),
+			}
+			_, err = Install(
+				context.Background(), root, "1.0.0", b, f, download.MakeLocalFileFetcher(),
+				InstallOptions{Max: 20, Keyring: tt.keyring},
+			)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Install() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var sigErr *kpkgerr.SignatureErr
+			if errors.As(err, &sigErr) != tt.wantSigError {
+				t.Errorf(
+					"Install() error = %v, wantSigError %v", err,
+					tt.wantSigError,
+				)
+			}
+		})
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	e := makeTestEntity(t)
+	dir := t.TempDir()
+
+	var binary bytes.Buffer
+	if err := e.Serialize(&binary); err != nil {
+		t.Fatalf("could not serialize key: %s", err)
+	}
+	binaryPath := filepath.Join(dir, "keyring.gpg")
+	if err := ioutil.WriteFile(binaryPath, binary.Bytes(), os.ModePerm); err != nil {
+		t.Fatalf("could not write keyring: %s", err)
+	}
+
+	keyring, err := LoadKeyring(binaryPath)
+	if err != nil {
+		t.Errorf("LoadKeyring() error = %v, expected nil", err)
+	}
+	if len(keyring) != 1 {
+		t.Errorf("LoadKeyring() got %d keys, want 1", len(keyring))
+	}
+
+	if _, err := LoadKeyring(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("LoadKeyring() expected error for missing keyring")
+	}
+}
+
+func TestHashicorpReleaseTool_Keyring(t *testing.T) {
+	keyring, err := MakeHashicorpReleaseTool("terraform").Keyring()
+	if err != nil {
+		t.Fatalf("Keyring() error = %v, expected nil", err)
+	}
+	if len(keyring) != 1 {
+		t.Errorf("Keyring() got %d keys, want 1", len(keyring))
+	}
+}
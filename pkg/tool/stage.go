@@ -0,0 +1,132 @@
+package tool
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// stagingDirName is the folder in the kpkg root where installs are prepared,
+// before they are moved into place. It is kept inside the root, so that moving the
+// prepared install is a rename on the same filesystem
+const stagingDirName = ".staging"
+
+// makeStagingDir creates a fresh dir to stage an install of the binary in
+func makeStagingDir(basePath, binary string) (string, error) {
+	stagingPath := filepath.Join(basePath, stagingDirName)
+	if err := os.MkdirAll(stagingPath, 0755); err != nil {
+		return "", err
+	}
+	return ioutil.TempDir(stagingPath, binary+"-")
+}
+
+// stageBinary streams the binary at src into the version dir staged at dst,
+// and flushes it to disk
+func stageBinary(src, dst, binary string) (err error) {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(
+		filepath.Join(dst, binary), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := out.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+	return out.Sync()
+}
+
+// swapDir moves the staged dir to target. If target already exists, it is moved into
+// backupPath first. The returned func restores the previous state of target, and should
+// be called if the install fails after the swap
+func swapDir(staged, target, backupPath string) (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return nil, err
+	}
+
+	backedUp := false
+	if _, err := os.Stat(target); err == nil {
+		if err := os.Rename(target, backupPath); err != nil {
+			return nil, err
+		}
+		backedUp = true
+	}
+
+	restore := func() error {
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		if backedUp {
+			return os.Rename(backupPath, target)
+		}
+		return nil
+	}
+
+	if err := os.Rename(staged, target); err != nil {
+		if backedUp {
+			_ = os.Rename(backupPath, target)
+		}
+		return nil, err
+	}
+
+	if err := syncDir(filepath.Dir(target)); err != nil {
+		_ = restore()
+		return nil, err
+	}
+	return restore, nil
+}
+
+// linkBinary points the symlink of the binary in the bin folder, or the links folder if shims
+// are enabled, to binaryPath. The new symlink is created in the staging dir, and renamed over
+// the old one, so the binary is never left without a symlink
+func linkBinary(basePath, stagingPath, binaryPath, binary string) error {
+	binaryLinkPath := filepath.Join(linkDir(basePath), binary)
+	if info, err := os.Lstat(binaryLinkPath); err == nil && info.IsDir() {
+		return fmt.Errorf(
+			"could not replace symlink, path %s is a dir", binaryLinkPath,
+		)
+	}
+
+	tmpLinkPath := filepath.Join(stagingPath, binary+".link")
+	if err := os.Symlink(binaryPath, tmpLinkPath); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpLinkPath, binaryLinkPath); err != nil {
+		return fmt.Errorf(
+			"could not replace symlink at path %s: %w", binaryLinkPath, err,
+		)
+	}
+	return syncDir(filepath.Dir(binaryLinkPath))
+}
+
+// syncDir flushes the entries of a dir to disk, so that renames into it survive a crash.
+// Windows does not support syncing dirs, so it is a no-op there
+func syncDir(p string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(p)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
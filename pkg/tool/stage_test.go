@@ -0,0 +1,186 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/download"
+)
+
+type testBinary struct {
+	extractErr error
+}
+
+func (t testBinary) Name() string {
+	return "a"
+}
+
+func (t testBinary) ShortDesc() string {
+	return "a"
+}
+
+func (t testBinary) LongDesc() string {
+	return "a"
+}
+
+func (t testBinary) MakeUrl(version string) (string, error) {
+	return "https://some.url/a-" + version, nil
+}
+
+func (t testBinary) Versions(_ uint) ([]string, error) {
+	return []string{"1.0.0"}, nil
+}
+
+func (t testBinary) Extract(artifactPath, _ string) (string, error) {
+	return artifactPath, t.extractErr
+}
+
+type testContentsFileFetcher struct {
+	dir, contents string
+}
+
+func (t testContentsFileFetcher) FetchFile(_ context.Context, u string) (string, error) {
+	p := filepath.Join(t.dir, filepath.Base(u))
+	return p, ioutil.WriteFile(p, []byte(t.contents), os.ModePerm)
+}
+
+func installTestBinary(
+	t *testing.T, root, contents string, b Binary, force bool,
+) (string, error) {
+	return Install(
+		context.Background(), root, "latest", b,
+		testContentsFileFetcher{dir: t.TempDir(), contents: contents},
+		download.MakeLocalFileFetcher(),
+		InstallOptions{Force: force, Max: 20},
+	)
+}
+
+func TestInstall(t *testing.T) {
+	root, err := config.CreatePath(t.TempDir())
+	if err != nil {
+		t.Fatalf("could not create root: %s", err)
+	}
+
+	binaryPath, err := installTestBinary(t, root, "v1", testBinary{}, false)
+	if err != nil {
+		t.Fatalf("Install() error = %v, expected nil", err)
+	}
+	if v, err := LinkedVersion(root, "a"); err != nil || v != "1.0.0" {
+		t.Errorf("LinkedVersion() = %s, %v, want 1.0.0", v, err)
+	}
+
+	// a failed forced re-install should leave the previous install in place
+	if _, err := installTestBinary(
+		t, root, "v2", testBinary{extractErr: errors.New("failed")}, true,
+	); err == nil {
+		t.Errorf("Install() expected error, got nil")
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(root, "bin", "a"))
+	if err != nil {
+		t.Fatalf("could not read binary through symlink: %s", err)
+	}
+	if string(contents) != "v1" {
+		t.Errorf("expected previous binary to be kept, got contents %s", contents)
+	}
+
+	// a successful forced re-install replaces the binary
+	if _, err := installTestBinary(t, root, "v2", testBinary{}, true); err != nil {
+		t.Fatalf("Install() error = %v, expected nil", err)
+	}
+	contents, err = ioutil.ReadFile(binaryPath)
+	if err != nil {
+		t.Fatalf("could not read binary: %s", err)
+	}
+	if string(contents) != "v2" {
+		t.Errorf("expected binary to be replaced, got contents %s", contents)
+	}
+
+	// nothing should be left behind in the staging dir
+	staged, err := ioutil.ReadDir(filepath.Join(root, stagingDirName))
+	if err != nil {
+		t.Fatalf("could not read staging dir: %s", err)
+	}
+	if len(staged) != 0 {
+		t.Errorf("expected staging dir to be empty, found %d entries", len(staged))
+	}
+}
+
+func TestInstall_Cancelled(t *testing.T) {
+	root, err := config.CreatePath(t.TempDir())
+	if err != nil {
+		t.Fatalf("could not create root: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Install(
+		ctx, root, "latest", testBinary{},
+		testContentsFileFetcher{dir: t.TempDir(), contents: "v1"},
+		download.MakeLocalFileFetcher(), InstallOptions{Max: 20},
+	); !errors.Is(err, context.Canceled) {
+		t.Errorf("Install() error = %v, expected context canceled", err)
+	}
+	if installed, err := Installed(root, "a", "1.0.0"); err != nil || installed {
+		t.Errorf("Installed() = %v, %v, expected nothing to be installed", installed, err)
+	}
+}
+
+func Test_swapDir(t *testing.T) {
+	base := t.TempDir()
+	staged := filepath.Join(base, "staged")
+	target := filepath.Join(base, "tool", "1.0.0")
+	backup := filepath.Join(base, "backup")
+	for p, contents := range map[string]string{staged: "new", target: "old"} {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatalf("could not create dir: %s", err)
+		}
+		if err := ioutil.WriteFile(
+			filepath.Join(p, "a"), []byte(contents), os.ModePerm,
+		); err != nil {
+			t.Fatalf("could not create file: %s", err)
+		}
+	}
+
+	restore, err := swapDir(staged, target, backup)
+	if err != nil {
+		t.Fatalf("swapDir() error = %v, expected nil", err)
+	}
+	if contents, _ := ioutil.ReadFile(filepath.Join(target, "a")); string(contents) != "new" {
+		t.Errorf("expected staged dir to be swapped in, got %s", contents)
+	}
+
+	if err := restore(); err != nil {
+		t.Fatalf("restore() error = %v, expected nil", err)
+	}
+	if contents, _ := ioutil.ReadFile(filepath.Join(target, "a")); string(contents) != "old" {
+		t.Errorf("expected previous dir to be restored, got %s", contents)
+	}
+}
+
+func Test_linkBinary(t *testing.T) {
+	root, err := config.CreatePath(t.TempDir())
+	if err != nil {
+		t.Fatalf("could not create root: %s", err)
+	}
+	for _, v := range []string{"1.0.0", "2.0.0"} {
+		if err := os.MkdirAll(filepath.Join(root, "a", v), 0755); err != nil {
+			t.Fatalf("could not create dir: %s", err)
+		}
+		if _, err := os.Create(filepath.Join(root, "a", v, "a")); err != nil {
+			t.Fatalf("could not create binary: %s", err)
+		}
+		if err := linkBinary(
+			root, t.TempDir(), filepath.Join(root, "a", v, "a"), "a",
+		); err != nil {
+			t.Fatalf("linkBinary() error = %v, expected nil", err)
+		}
+		if linked, err := LinkedVersion(root, "a"); err != nil || linked != v {
+			t.Errorf("LinkedVersion() = %s, %v, want %s", linked, err, v)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package terraform
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/Masterminds/semver"
@@ -15,6 +16,7 @@ type terraformTool struct {
 	arch,
 	os string
 	tool.GithubReleaseTool
+	tool.HashicorpReleaseTool
 }
 
 func (l terraformTool) Extract(artifactPath, _ string) (string, error) {
@@ -75,10 +77,20 @@ func (l terraformTool) MakeUrl(version string) (string, error) {
 	return url, nil
 }
 
+func (l terraformTool) ChecksumUrl(version string) (string, string, error) {
+	url, err := l.MakeUrl(version)
+	if err != nil {
+		return "", "", err
+	}
+	sumsUrl, err := l.MakeSumsUrl(version)
+	return sumsUrl, path.Base(url), err
+}
+
 func MakeBinary(os, arch string) tool.Binary {
 	return terraformTool{
-		arch:              arch,
-		os:                os,
-		GithubReleaseTool: tool.MakeGithubReleaseTool("hashicorp", "terraform"),
+		arch:                 arch,
+		os:                   os,
+		GithubReleaseTool:    tool.MakeGithubReleaseTool("hashicorp", "terraform"),
+		HashicorpReleaseTool: tool.MakeHashicorpReleaseTool("terraform"),
 	}
 }
@@ -1,16 +1,21 @@
 package tool
 
 import (
+	"context"
+	"crypto"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/Masterminds/semver"
+	"golang.org/x/crypto/openpgp"
 
 	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
 	"github.com/spachava753/kpkg/pkg/util"
 )
 
@@ -43,75 +48,200 @@ type Binary interface {
 	Extract(artifactPath, version string) (string, error)
 }
 
+// ContextVersioner is an optional interface that a Binary can implement, so that listing its
+// versions stops early when the context is done. Note that a Binary overriding the Versions
+// method of an embedded GithubReleaseTool should override VersionsContext as well
+type ContextVersioner interface {
+	// VersionsContext is like Versions, but stops early if ctx is done
+	VersionsContext(ctx context.Context, max uint) ([]string, error)
+}
+
+// Versions lists the installation candidates of the binary. Binaries that don't implement
+// ContextVersioner can't be cancelled, so Versions stops waiting on them once ctx is done.
+// Rate limit errors of the GitHub API are returned as a RateLimitErr
+func Versions(ctx context.Context, b Binary, max uint) ([]string, error) {
+	if v, ok := b.(ContextVersioner); ok {
+		versions, err := v.VersionsContext(ctx, max)
+		return versions, githubErr(err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		versions []string
+		err      error
+	}
+	// buffered, so that the goroutine can exit if no one is waiting on it anymore
+	results := make(chan result, 1)
+	go func() {
+		versions, err := b.Versions(max)
+		results <- result{versions: versions, err: err}
+	}()
+	select {
+	case r := <-results:
+		return r.versions, githubErr(r.err)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// VersionSource lists the versions of binaries in place of asking the source of a binary every time,
+// for example from a local index
+type VersionSource interface {
+	// Versions lists the installation candidates of the binary, like Versions
+	Versions(ctx context.Context, b Binary, max uint) ([]string, error)
+	// Refresh is like Versions, but always asks the source of the binary. It is used when a
+	// version can't be found, in case it was released after the versions were last listed
+	Refresh(ctx context.Context, b Binary, max uint) ([]string, error)
+}
+
+// InstallOptions configures how Install installs a binary
+type InstallOptions struct {
+	// Force re-installs the version, even if it is already installed
+	Force bool
+	// Windows installs the binary with an .exe extension
+	Windows bool
+	// SkipChecksum skips verifying the downloaded artifact
+	SkipChecksum bool
+	// Max is the max number of versions to search through
+	Max uint
+	// Keyring, if not nil, replaces the keys bundled with a binary that are trusted
+	// to sign its checksum file
+	Keyring openpgp.EntityList
+	// PublicKey is the key trusted to sign artifacts checked by a Binary's verifiers
+	PublicKey crypto.PublicKey
+	// KpkgVersion, Os and Arch are recorded in the receipt of the install
+	KpkgVersion, Os, Arch string
+	// FromFile installs from a local artifact instead of downloading it. It is either the path
+	// of the artifact, or of a dir of artifacts named like the last element of their url
+	FromFile string
+	// Versions, if not nil, lists the versions of the binary in place of the binary itself
+	Versions VersionSource
+	// Rewriter, if not nil, rewrites the urls of everything that is downloaded, for example
+	// to download from a mirror
+	Rewriter *download.Rewriter
+	// DryRun only prints what would be downloaded, without installing anything
+	DryRun bool
+	// Sha256, if set, is the digest that the artifact must have, for example from a lockfile.
+	// It is verified on top of the published checksum, and must agree with it
+	Sha256 string
+	// Shim is the executable that the binary is linked to in the bin folder if shims are enabled,
+	// see ShimsEnabled
+	Shim string
+	// NoLink installs the version without linking it, so the linked version stays as it is
+	NoLink bool
+	// Out is where the install prints what it is doing. If nil, it prints to stdout
+	Out io.Writer
+	// RequireChecksum fails the install, instead of printing a warning, if the artifact can't be
+	// verified against a published checksum or Sha256
+	RequireChecksum bool
+}
+
+// output returns where the install prints what it is doing
+func (o InstallOptions) output() io.Writer {
+	if o.Out != nil {
+		return o.Out
+	}
+	return os.Stdout
+}
+
+// Install downloads and installs a version of a binary, and symlinks it into the bin folder.
+// f is used to download artifacts, while u unpacks the downloaded artifact before it is
+// handed off to the binary for extraction. If the binary implements Checksummer, the
+// downloaded artifact is verified, unless opts.SkipChecksum is true.
+// If opts.FromFile is set, the artifact is read from local files instead, without any network access.
+// If opts.DryRun is set, the urls that would be downloaded are printed instead, and nothing is installed.
+// The install is abandoned if ctx is done before the new version is moved into place.
+// Once it is, the install is completed regardless, so the symlink is never left half swapped
 func Install(
-	basePath, version string, force, windows bool, max uint, b Binary,
-	f download.FileFetcher,
+	ctx context.Context, basePath, version string, b Binary, f, u download.FileFetcher,
+	opts InstallOptions,
 ) (s string, err error) {
 	binary := b.Name()
-	if windows {
+	if opts.Windows {
 		binary = binary + ".exe"
 	}
 
-	fmt.Printf("installing %s...\n", binary)
+	if opts.Rewriter != nil {
+		f, err = download.MakeRewriteFileFetcher(opts.Rewriter, f)
+		if err != nil {
+			return "", err
+		}
+	}
 
-	// check that the version exists
-	fmt.Println("verifying version info")
-	versions, err := b.Versions(max)
+	// lock the binary, so that other kpkg processes don't modify it at the same time
+	unlock, err := lockBinary(ctx, basePath, binary)
 	if err != nil {
 		return "", err
 	}
+	defer func() {
+		if e := unlock(); e != nil && err == nil {
+			err = e
+		}
+	}()
 
-	if version != "latest" {
-		v, err := semver.NewVersion(version)
-		if err != nil {
+	out := opts.output()
+	fmt.Fprintf(out, "installing %s...\n", binary)
+
+	// a version that is installed already is not listed again, so that it can be relinked
+	// without network access, even if it is older than the opts.Max latest versions
+	installed, checked := false, false
+	if v, e := semver.NewVersion(version); e == nil && !opts.DryRun {
+		version, checked = v.String(), true
+		fmt.Fprintln(out, "checking for local installation")
+		if installed, err = Installed(basePath, binary, version); err != nil {
 			return "", err
 		}
-		version = v.String()
-		if !util.ContainsString(versions, version) {
-			return "", fmt.Errorf(
-				"version %s is not valid for binary %s", version, binary,
-			)
+	}
+	if !installed {
+		version, err = resolveVersion(ctx, b, binary, version, opts)
+		if err != nil {
+			return "", err
 		}
 	}
 
-	if version == "latest" {
-		version = versions[0]
+	binaryVersionPath := filepath.Join(basePath, binary, version)
+	binaryPath := filepath.Join(binaryVersionPath, binary)
+
+	if opts.DryRun {
+		return binaryPath, printDryRun(b, binary, version, opts)
 	}
 
-	binaryBasePath := filepath.Join(basePath, binary)
-	binaryVersionPath := filepath.Join(binaryBasePath, version)
-	binaryPath := filepath.Join(binaryVersionPath, binary)
-	binaryLinkPath := filepath.Join(basePath, "bin", binary)
+	// check if installed already, now that "latest" is resolved
+	if !checked {
+		fmt.Fprintln(out, "checking for local installation")
+		if installed, err = Installed(basePath, binary, version); err != nil {
+			return "", err
+		}
+	}
 
-	// check if installed already
-	fmt.Println("checking for local installation")
-	installed, err := Installed(basePath, binary, version)
+	// installs are prepared in a staging dir, and only moved into place once complete
+	stagingPath, err := makeStagingDir(basePath, binary)
 	if err != nil {
 		return "", err
 	}
+	defer func() {
+		if e := os.RemoveAll(stagingPath); e != nil && err == nil {
+			err = e
+		}
+	}()
 
 	if installed {
 		// since we already have it installed, set the symlink to this
-		fmt.Println("tool already installed!")
-		if !force {
-			fmt.Println("setting symlink")
-			if err = os.Remove(binaryLinkPath); err != nil {
-				if !os.IsNotExist(err) {
-					return "", fmt.Errorf(
-						"could not remove symlink to path %s: %w",
-						binaryLinkPath, err,
-					)
-				}
-			}
-			return binaryPath, os.Symlink(
-				binaryPath, filepath.Join(basePath, "bin", binary),
-			)
+		fmt.Fprintln(out, "tool already installed!")
+		if !opts.Force && opts.NoLink {
+			return binaryPath, nil
 		}
-		// since force is enabled, remove the file and continue
-		fmt.Println("removing local installation")
-		if err := os.Remove(binaryPath); err != nil {
-			return "", err
+		if !opts.Force {
+			fmt.Fprintln(out, "setting symlink")
+			return binaryPath, link(
+				basePath, stagingPath, binaryPath, binary, opts.Shim,
+			)
 		}
+		// since force is enabled, continue. The local installation is
+		// only replaced once the new one is ready
+		fmt.Fprintln(out, "re-installing")
 	}
 
 	// construct the url to fetch the release
@@ -120,20 +250,123 @@ func Install(
 		return "", err
 	}
 
+	// when installing from local files, artifacts are read from a dir instead of being downloaded,
+	// along with any checksum file or signature next to them
+	source, localDir, localArtifact := opts.Rewriter.Rewrite(url), "", ""
+	if opts.FromFile != "" {
+		localDir, localArtifact, err = localArtifactPaths(opts.FromFile, url)
+		if err != nil {
+			return "", err
+		}
+		source = localArtifact
+		f = download.MakeDirFileFetcher(localDir, stagingPath)
+	}
+
+	// fetch the expected checksum first, so that a cached artifact can be found by its contents
+	var artifact, expectedSum, unverified string
+	switch c, ok := b.(Checksummer); {
+	case opts.SkipChecksum:
+		if opts.RequireChecksum {
+			unverified = "checksum verification is skipped"
+			break
+		}
+		fmt.Fprintln(out, "skipping checksum verification")
+	case opts.Sha256 != "" && (!ok || localDir != "" && !hasLocalChecksum(c, version, localDir)):
+		// there is no published checksum, the artifact is only verified against the pinned digest
+	case !ok:
+		unverified = fmt.Sprintf("no checksum available for %s", binary)
+	case localDir != "" && !hasLocalChecksum(c, version, localDir):
+		unverified = fmt.Sprintf("no checksum file for %s found in %s", binary, localDir)
+	default:
+		fmt.Fprintln(out, "fetching checksum")
+		artifact, expectedSum, err = expectedChecksum(
+			ctx, c, version, opts.Keyring, f, out,
+		)
+		// a version released without a checksum can still be verified against the pinned digest
+		var noChecksumErr *kpkgerr.NoChecksumErr
+		if errors.As(err, &noChecksumErr) {
+			err = nil
+			if opts.Sha256 == "" {
+				unverified = noChecksumErr.Error()
+			}
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if unverified != "" {
+		if opts.RequireChecksum {
+			name, err := urlFileName(url)
+			if err != nil {
+				return "", err
+			}
+			return "", &kpkgerr.UnverifiedErr{Artifact: name, Reason: unverified}
+		}
+		fmt.Fprintf(out, "warning: %s, skipping verification\n", unverified)
+	}
+
+	if opts.Sha256 != "" {
+		if artifact == "" {
+			if artifact, err = urlFileName(url); err != nil {
+				return "", err
+			}
+		}
+		if expectedSum != "" && !strings.EqualFold(expectedSum, opts.Sha256) {
+			return "", fmt.Errorf(
+				"published checksum of %s does not match the pinned checksum: %w", binary,
+				&kpkgerr.ChecksumErr{Artifact: artifact, Expected: opts.Sha256, Actual: expectedSum},
+			)
+		}
+		expectedSum = opts.Sha256
+	}
+
 	// download CLI
-	fmt.Println("downloading from tool from ", url)
-	tmpFilePath, err := f.FetchFile(url)
+	var artifactPath string
+	if localArtifact != "" {
+		fmt.Fprintln(out, "reading tool from", localArtifact)
+		artifactPath, err = copyArtifact(localArtifact, stagingPath)
+	} else {
+		fmt.Fprintln(out, "downloading from tool from ", source)
+		fetchCtx := ctx
+		if expectedSum != "" {
+			fetchCtx = download.WithSha256(ctx, expectedSum)
+		}
+		artifactPath, err = f.FetchFile(fetchCtx, url)
+	}
 	if err != nil {
 		return "", err
 	}
 	// cleanup temp file
 	defer func() {
-		if e := os.Remove(tmpFilePath); e == nil {
+		if e := os.Remove(artifactPath); e != nil && err == nil {
 			err = e
 		}
 	}()
 
-	fmt.Println("extracting...")
+	// verify the artifact before doing anything with it
+	if expectedSum != "" {
+		fmt.Fprintln(out, "verifying checksum")
+		if err := compareChecksum(artifactPath, artifact, expectedSum); err != nil {
+			return "", err
+		}
+	}
+
+	if v, ok := b.(Verifiable); ok {
+		for _, verifier := range v.Verifiers() {
+			if err := verifier.Verify(
+				ctx, url, artifactPath, opts.PublicKey, f, out,
+			); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	tmpFilePath, err := u.FetchFile(ctx, artifactPath)
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Fprintln(out, "extracting...")
 	tmpFilePath, err = b.Extract(tmpFilePath, version)
 	if err != nil {
 		return "", err
@@ -143,51 +376,133 @@ func Install(
 	}
 
 	// copy to our bin path
-	fmt.Println("installing...")
-	// create binary file
-	if _, err := os.Stat(binaryVersionPath); os.IsNotExist(err) {
-		if err := os.MkdirAll(binaryVersionPath, os.ModePerm); err != nil {
-			return "", err
-		}
+	fmt.Fprintln(out, "installing...")
+	stagedVersionPath := filepath.Join(stagingPath, version)
+	if err := stageBinary(tmpFilePath, stagedVersionPath, binary); err != nil {
+		return "", err
 	}
-
-	// copy the downloaded binary to path
-	contents, err := ioutil.ReadFile(tmpFilePath)
+	receipt, err := makeReceipt(
+		binary, version, source, artifactPath,
+		filepath.Join(stagedVersionPath, binary), opts,
+	)
 	if err != nil {
 		return "", err
 	}
-	if err := ioutil.WriteFile(binaryPath, contents, os.ModePerm); err != nil {
+	if err := writeReceipt(stagedVersionPath, receipt); err != nil {
 		return "", err
 	}
 
+	// last chance to abandon the install. Past this point, the install is finished
+	// even if ctx is done, so that the version dir and symlink are never left half swapped
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	// move the staged install into place, keeping the previous one around until
+	// the symlink is set, in case we need to roll back
+	restore, err := swapDir(
+		stagedVersionPath, binaryVersionPath,
+		filepath.Join(stagingPath, version+".old"),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.NoLink {
+		return binaryPath, nil
+	}
+
 	// create symlink to bin path
-	if info, err := os.Stat(binaryLinkPath); err == nil {
-		if info.IsDir() {
+	if err := link(basePath, stagingPath, binaryPath, binary, opts.Shim); err != nil {
+		if e := restore(); e != nil {
 			return "", fmt.Errorf(
-				"could not remove symlink, path %s is a dir", binaryLinkPath,
+				"could not roll back installation after error %s: %w", err, e,
 			)
 		}
-		if err = os.Remove(binaryLinkPath); err != nil {
+		return "", err
+	}
+	return binaryPath, nil
+}
+
+// resolveVersion checks that the version exists, and resolves "latest" to the latest version.
+// Versions can't be listed when installing from local files, so the version must be given
+// explicitly, and is only checked to be a valid semver version. Neither is a version pinned
+// with opts.Sha256 listed, since its artifact is verified against the digest anyway, and the
+// version may be older than the opts.Max latest versions
+func resolveVersion(
+	ctx context.Context, b Binary, binary, version string, opts InstallOptions,
+) (string, error) {
+	if opts.FromFile != "" && version == "latest" {
+		return "", fmt.Errorf(
+			"a version is required to install %s from %s", binary, opts.FromFile,
+		)
+	}
+	if version != "latest" && (opts.FromFile != "" || opts.Sha256 != "") {
+		v, err := semver.NewVersion(version)
+		if err != nil {
+			return "", err
+		}
+		return v.String(), nil
+	}
+
+	// check that the version exists
+	fmt.Fprintln(opts.output(), "verifying version info")
+	list := Versions
+	if opts.Versions != nil {
+		list = opts.Versions.Versions
+	}
+	versions, err := list(ctx, b, opts.Max)
+	if err != nil {
+		return "", err
+	}
+
+	if version != "latest" {
+		v, err := semver.NewVersion(version)
+		if err != nil {
+			return "", err
+		}
+		version = v.String()
+		if !util.ContainsString(versions, version) && opts.Versions != nil {
+			// the version may have been released after the versions were last listed
+			if versions, err = opts.Versions.Refresh(ctx, b, opts.Max); err != nil {
+				return "", err
+			}
+		}
+		if !util.ContainsString(versions, version) {
 			return "", fmt.Errorf(
-				"could not remove symlink to path %s: %w", binaryLinkPath, err,
+				"version %s is not valid for binary %s", version, binary,
 			)
 		}
 	}
-	return binaryPath, os.Symlink(
-		binaryPath, filepath.Join(basePath, "bin", binary),
-	)
+
+	if version == "latest" {
+		version = versions[0]
+	}
+	return version, nil
 }
 
 // RemoveVersions will remove the binary version at the provided path
 // basePath is the path where the .kpkg folder is located
 // binary is the binary name
 // versions is a list of versions to remove
-func RemoveVersions(basePath string, binary string, versions []string) error {
+func RemoveVersions(
+	ctx context.Context, basePath string, binary string, versions []string,
+) (err error) {
 	// check that supplied versions are valid
 	if len(versions) == 0 {
 		return fmt.Errorf("not enough versions were passed in")
 	}
 
+	unlock, err := lockBinary(ctx, basePath, binary)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := unlock(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
 	installedVersion, err := LinkedVersion(basePath, binary)
 	if err != nil {
 		return err
@@ -208,10 +523,24 @@ func RemoveVersions(basePath string, binary string, versions []string) error {
 }
 
 // Purge will remove all binary versions at the provided path
-func Purge(basePath, binary string) error {
-	// remove the symlink if exists
-	if err := os.Remove(filepath.Join(basePath, "bin", binary)); err != nil {
-		if !os.IsNotExist(err) {
+func Purge(ctx context.Context, basePath, binary string) (err error) {
+	unlock, err := lockBinary(ctx, basePath, binary)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := unlock(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	// remove the symlink if exists, along with the shim if shims are enabled
+	links := []string{filepath.Join(basePath, "bin", binary)}
+	if ShimsEnabled(basePath) {
+		links = append(links, filepath.Join(basePath, linksDirName, binary))
+	}
+	for _, l := range links {
+		if err := os.Remove(l); err != nil && !os.IsNotExist(err) {
 			return err
 		}
 	}
@@ -244,18 +573,14 @@ func LinkedVersion(basePath, binary string) (string, error) {
 		return "", fmt.Errorf("given path is not a dir: %s", basePath)
 	}
 
-	symPath := filepath.Join(basePath, "bin", binary)
+	symPath := filepath.Join(linkDir(basePath), binary)
 
 	// symlink doesn't exist
 	if _, err := os.Readlink(symPath); err != nil {
 		return "", nil
 	}
 	// returns an err for broken symlink
-	linkPath, err := filepath.EvalSymlinks(
-		filepath.Join(
-			basePath, "bin", binary,
-		),
-	)
+	linkPath, err := filepath.EvalSymlinks(symPath)
 	if err != nil {
 		return "", err
 	}
@@ -341,7 +666,7 @@ func ListToolVersionsInstalled(basePath, binary string) ([]string, error) {
 func ListInstalled(basePath string) ([]string, error) {
 	var installedBinaries []string
 
-	basePath = filepath.Join(basePath, "bin")
+	basePath = linkDir(basePath)
 
 	binaryPathInfo, err := os.Stat(basePath)
 	if err == nil {
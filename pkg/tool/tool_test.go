@@ -1,13 +1,17 @@
 package tool
 
 import (
+	"context"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/spachava753/kpkg/pkg/config"
+	"github.com/spachava753/kpkg/pkg/download"
 	"github.com/spachava753/kpkg/pkg/util"
 )
 
@@ -648,7 +652,7 @@ func TestRemoveVersions(t *testing.T) {
 					}
 				}
 				if err := RemoveVersions(
-					p, tt.args.binary, tt.args.versions,
+					context.Background(), p, tt.args.binary, tt.args.versions,
 				); (err != nil) != tt.wantErr {
 					t.Errorf(
 						"RemoveVersions() error = %v, wantErr %v", err,
@@ -1156,7 +1160,7 @@ func TestPurge(t *testing.T) {
 						return
 					}
 				}
-				if err := Purge(p, tt.args.binary); (err != nil) != tt.wantErr {
+				if err := Purge(context.Background(), p, tt.args.binary); (err != nil) != tt.wantErr {
 					t.Errorf("Purge() error = %v, wantErr %v", err, tt.wantErr)
 				}
 			},
@@ -1220,3 +1224,120 @@ func TestListInstalled(t *testing.T) {
 		},
 	)
 }
+
+// blockingBinary is a Binary that does not support cancellation, and only lists
+// its versions once unblocked
+type blockingBinary struct {
+	testBinary
+	unblock chan struct{}
+}
+
+func (b blockingBinary) Versions(max uint) ([]string, error) {
+	<-b.unblock
+	return b.testBinary.Versions(max)
+}
+
+func TestVersions(t *testing.T) {
+	b := blockingBinary{unblock: make(chan struct{})}
+	defer close(b.unblock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := Versions(ctx, b, 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Versions() error = %v, expected deadline exceeded", err)
+	}
+
+	versions, err := Versions(context.Background(), testBinary{}, 10)
+	if err != nil {
+		t.Fatalf("Versions() error = %v, expected nil", err)
+	}
+	if !reflect.DeepEqual(versions, []string{"1.0.0"}) {
+		t.Errorf("Versions() = %v, want [1.0.0]", versions)
+	}
+}
+
+// staleVersionSource lists versions from before the latest release, until it is refreshed
+type staleVersionSource struct {
+	refreshed bool
+}
+
+func (s *staleVersionSource) Versions(_ context.Context, _ Binary, _ uint) ([]string, error) {
+	return []string{"0.9.0"}, nil
+}
+
+func (s *staleVersionSource) Refresh(ctx context.Context, b Binary, max uint) ([]string, error) {
+	s.refreshed = true
+	return Versions(ctx, b, max)
+}
+
+func TestInstall_VersionSource(t *testing.T) {
+	tests := []struct {
+		name          string
+		version       string
+		wantVersion   string
+		wantRefreshed bool
+	}{
+		{
+			name:        "latest version of the source",
+			version:     "latest",
+			wantVersion: "0.9.0",
+		},
+		{
+			name:          "version released since the source was listed",
+			version:       "1.0.0",
+			wantVersion:   "1.0.0",
+			wantRefreshed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := config.CreatePath(t.TempDir())
+			if err != nil {
+				t.Fatalf("could not create root: %s", err)
+			}
+			source := &staleVersionSource{}
+			binaryPath, err := Install(
+				context.Background(), root, tt.version, testBinary{},
+				testContentsFileFetcher{dir: t.TempDir(), contents: "v1"},
+				download.MakeLocalFileFetcher(),
+				InstallOptions{Max: 20, Versions: source},
+			)
+			if err != nil {
+				t.Fatalf("Install() error = %v, expected nil", err)
+			}
+			if got := filepath.Base(filepath.Dir(binaryPath)); got != tt.wantVersion {
+				t.Errorf("Install() installed version %s, want %s", got, tt.wantVersion)
+			}
+			if source.refreshed != tt.wantRefreshed {
+				t.Errorf("refreshed = %v, want %v", source.refreshed, tt.wantRefreshed)
+			}
+		})
+	}
+}
+
+// failingFileFetcher fails every fetch, for installs that should not download anything
+type failingFileFetcher struct{}
+
+func (failingFileFetcher) FetchFile(_ context.Context, u string) (string, error) {
+	return "", errors.New("unexpected fetch of " + u)
+}
+
+func TestInstall_DryRun(t *testing.T) {
+	root, err := config.CreatePath(t.TempDir())
+	if err != nil {
+		t.Fatalf("could not create root: %s", err)
+	}
+	binaryPath, err := Install(
+		context.Background(), root, "latest", testBinary{}, failingFileFetcher{},
+		download.MakeLocalFileFetcher(), InstallOptions{Max: 20, DryRun: true},
+	)
+	if err != nil {
+		t.Fatalf("Install() error = %v, expected nil", err)
+	}
+	if want := filepath.Join(root, "a", "1.0.0", "a"); binaryPath != want {
+		t.Errorf("Install() = %s, want %s", binaryPath, want)
+	}
+	if installed, err := Installed(root, "a", "1.0.0"); err != nil || installed {
+		t.Errorf("Installed() = %v, %v, expected nothing to be installed", installed, err)
+	}
+}
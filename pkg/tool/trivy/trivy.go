@@ -3,6 +3,7 @@ package trivy
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/Masterminds/semver"
@@ -105,10 +106,25 @@ func (l trivyTool) MakeUrl(version string) (string, error) {
 	return url + ".tar.gz", nil
 }
 
+func (l trivyTool) ChecksumUrl(version string) (string, string, error) {
+	url, err := l.MakeUrl(version)
+	if err != nil {
+		return "", "", err
+	}
+	version = semver.MustParse(version).String()
+	return fmt.Sprintf(
+		"%sv%s/trivy_%s_checksums.txt", l.MakeReleaseUrl(), version, version,
+	), path.Base(url), nil
+}
+
 func MakeBinary(os, arch string) tool.Binary {
 	return trivyTool{
-		arch:              arch,
-		os:                os,
-		GithubReleaseTool: tool.MakeGithubReleaseTool("aquasecurity", "trivy"),
+		arch: arch,
+		os:   os,
+		GithubReleaseTool: tool.MakeGithubReleaseTool(
+			"aquasecurity", "trivy",
+		).WithVerifiers(
+			tool.MakeCosignVerifier(".sig", tool.VerifyIfPresent),
+		),
 	}
 }
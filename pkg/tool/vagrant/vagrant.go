@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -19,6 +20,7 @@ import (
 type vagrantTool struct {
 	arch,
 	os string
+	tool.HashicorpReleaseTool
 }
 
 func (l vagrantTool) Extract(artifactPath, _ string) (string, error) {
@@ -69,10 +71,14 @@ func (l vagrantTool) MakeUrl(version string) (string, error) {
 }
 
 func (l vagrantTool) Versions(max uint) ([]string, error) {
-	client := github.NewClient(nil)
+	return l.VersionsContext(context.Background(), max)
+}
+
+func (l vagrantTool) VersionsContext(ctx context.Context, max uint) ([]string, error) {
+	client := tool.GithubClient()
 	var resp *github.Response
 	releases, resp, err := client.Repositories.ListTags(
-		context.Background(), "hashicorp", "vagrant", nil,
+		ctx, "hashicorp", "vagrant", nil,
 	)
 	if err != nil {
 		return nil, err
@@ -80,7 +86,7 @@ func (l vagrantTool) Versions(max uint) ([]string, error) {
 	var r []*github.RepositoryTag
 	for resp != nil && resp.NextPage != resp.LastPage && len(releases) < int(max) {
 		r, resp, err = client.Repositories.ListTags(
-			context.Background(), "hashicorp", "vagrant", &github.ListOptions{
+			ctx, "hashicorp", "vagrant", &github.ListOptions{
 				Page:    resp.NextPage,
 				PerPage: int(max) - len(releases),
 			},
@@ -122,9 +128,19 @@ func (l vagrantTool) Versions(max uint) ([]string, error) {
 	return versions, nil
 }
 
+func (l vagrantTool) ChecksumUrl(version string) (string, string, error) {
+	url, err := l.MakeUrl(version)
+	if err != nil {
+		return "", "", err
+	}
+	sumsUrl, err := l.MakeSumsUrl(version)
+	return sumsUrl, path.Base(url), err
+}
+
 func MakeBinary(os, arch string) tool.Binary {
 	return vagrantTool{
-		arch: arch,
-		os:   os,
+		arch:                 arch,
+		os:                   os,
+		HashicorpReleaseTool: tool.MakeHashicorpReleaseTool("vagrant"),
 	}
 }
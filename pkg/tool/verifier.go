@@ -0,0 +1,194 @@
+package tool
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+// Verifier verifies a downloaded artifact before it is installed, for example by checking
+// a signature published alongside the artifact
+type Verifier interface {
+	// Verify checks the artifact at artifactPath, which was downloaded from url.
+	// key is the public key configured by the user, and may be nil. f can be used to fetch
+	// any companion assets needed for verification, and ctx should be passed along to it.
+	// What is being verified, and any warnings, are printed to out
+	Verify(
+		ctx context.Context, url, artifactPath string, key crypto.PublicKey,
+		f download.FileFetcher, out io.Writer,
+	) error
+}
+
+// Verifiable is an optional interface that a Binary can implement to have its
+// downloaded artifacts checked by verifiers
+type Verifiable interface {
+	// Verifiers returns the verifiers to run against a downloaded artifact
+	Verifiers() []Verifier
+}
+
+// VerifyPolicy decides whether a missing or invalid signature blocks an install
+type VerifyPolicy int
+
+const (
+	// VerifyWarn only prints a warning if a signature is missing or invalid
+	VerifyWarn VerifyPolicy = iota
+	// VerifyIfPresent skips the signature if no public key is configured. Once a key is configured,
+	// it blocks the install if a signature is missing or invalid
+	VerifyIfPresent
+	// VerifyRequire blocks the install if a signature is missing or invalid
+	VerifyRequire
+)
+
+type cosignVerifier struct {
+	// suffix appended to the artifact url to get the url of the companion asset
+	suffix string
+	policy VerifyPolicy
+}
+
+// cosignBundle is the subset of a cosign bundle needed to verify a signature with a public key
+type cosignBundle struct {
+	Base64Signature string `json:"base64Signature"`
+}
+
+func (c cosignVerifier) Verify(
+	ctx context.Context, url, artifactPath string, key crypto.PublicKey, f download.FileFetcher,
+	out io.Writer,
+) error {
+	artifact := filepath.Base(artifactPath)
+	if key == nil {
+		if c.policy == VerifyRequire {
+			return &kpkgerr.SignatureErr{
+				Artifact: artifact,
+				Err:      errors.New("a cosign public key is required, but none is configured"),
+			}
+		}
+		fmt.Fprintln(out, "no cosign public key configured, skipping signature verification")
+		return nil
+	}
+
+	fmt.Fprintln(out, "verifying cosign signature")
+	contents, err := c.fetchCompanion(ctx, url, f)
+	if err != nil {
+		// local artifacts may not have a signature next to them either
+		var statusErr *kpkgerr.HttpStatusErr
+		if (errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound) ||
+			errors.Is(err, os.ErrNotExist) {
+			return c.handle(
+				out, artifact, fmt.Errorf("signature %s not found", url+c.suffix),
+				c.policy != VerifyWarn,
+			)
+		}
+		return err
+	}
+
+	sig, err := c.decodeSignature(contents)
+	if err == nil {
+		err = verifyBlob(key, artifactPath, sig)
+	}
+	if err != nil {
+		return c.handle(out, artifact, err, c.policy != VerifyWarn)
+	}
+	return nil
+}
+
+// handle either fails the verification, or prints a warning and lets it pass
+func (c cosignVerifier) handle(out io.Writer, artifact string, err error, block bool) error {
+	if block {
+		return &kpkgerr.SignatureErr{Artifact: artifact, Err: err}
+	}
+	fmt.Fprintf(out, "warning: could not verify signature of %s: %s\n", artifact, err)
+	return nil
+}
+
+// fetchCompanion fetches the companion asset of the artifact, and returns its contents
+func (c cosignVerifier) fetchCompanion(
+	ctx context.Context, url string, f download.FileFetcher,
+) ([]byte, error) {
+	sigPath, err := f.FetchFile(ctx, url+c.suffix)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(sigPath)
+	return ioutil.ReadFile(sigPath)
+}
+
+// decodeSignature decodes the signature held by the companion asset
+func (c cosignVerifier) decodeSignature(contents []byte) ([]byte, error) {
+	encoded := string(contents)
+	if strings.HasSuffix(c.suffix, ".bundle") {
+		var b cosignBundle
+		if err := json.Unmarshal(contents, &b); err != nil {
+			return nil, fmt.Errorf("could not parse bundle: %w", err)
+		}
+		encoded = b.Base64Signature
+	}
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+}
+
+// verifyBlob checks a signature over the contents of the file at the path,
+// the same way "cosign verify-blob --key" does
+func verifyBlob(key crypto.PublicKey, p string, sig []byte) error {
+	contents, err := ioutil.ReadFile(p)
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(contents)
+
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, digest[:], sig) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig)
+	case ed25519.PublicKey:
+		if !ed25519.Verify(k, contents, sig) {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil
+}
+
+// LoadPublicKey reads a PEM encoded public key, like the cosign.pub file generated by
+// "cosign generate-key-pair"
+func LoadPublicKey(p string) (crypto.PublicKey, error) {
+	contents, err := ioutil.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, fmt.Errorf("could not find a PEM block in %s", p)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// MakeCosignVerifier creates a verifier that checks the cosign signature published
+// next to an artifact. suffix is appended to the artifact url to get the companion asset,
+// either a ".sig" file containing a base64 encoded signature, or a ".bundle" file
+func MakeCosignVerifier(suffix string, policy VerifyPolicy) Verifier {
+	return cosignVerifier{
+		suffix: suffix,
+		policy: policy,
+	}
+}
@@ -0,0 +1,205 @@
+package tool
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spachava753/kpkg/pkg/download"
+	kpkgerr "github.com/spachava753/kpkg/pkg/error"
+)
+
+func makeTestEcdsaKey(t *testing.T) *ecdsa.PrivateKey {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate key: %s", err)
+	}
+	return k
+}
+
+func signTestBlob(t *testing.T, k *ecdsa.PrivateKey, blob string) string {
+	digest := sha256.Sum256([]byte(blob))
+	sig, err := ecdsa.SignASN1(rand.Reader, k, digest[:])
+	if err != nil {
+		t.Fatalf("could not sign blob: %s", err)
+	}
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func Test_cosignVerifier_Verify(t *testing.T) {
+	signingKey := makeTestEcdsaKey(t)
+	otherKey := makeTestEcdsaKey(t)
+	validSig := signTestBlob(t, signingKey, "hello")
+
+	tests := []struct {
+		name         string
+		suffix       string
+		policy       VerifyPolicy
+		key          crypto.PublicKey
+		companion    string
+		wantErr      bool
+		wantSigError bool
+	}{
+		{
+			name:      "valid signature",
+			suffix:    ".sig",
+			policy:    VerifyRequire,
+			key:       &signingKey.PublicKey,
+			companion: validSig,
+		},
+		{
+			name:      "valid bundle",
+			suffix:    ".bundle",
+			policy:    VerifyRequire,
+			key:       &signingKey.PublicKey,
+			companion: fmt.Sprintf(`{"base64Signature":"%s"}`, validSig),
+		},
+		{
+			name:         "invalid signature blocks",
+			suffix:       ".sig",
+			policy:       VerifyIfPresent,
+			key:          &otherKey.PublicKey,
+			companion:    validSig,
+			wantErr:      true,
+			wantSigError: true,
+		},
+		{
+			name:      "invalid signature warns",
+			suffix:    ".sig",
+			policy:    VerifyWarn,
+			key:       &otherKey.PublicKey,
+			companion: validSig,
+		},
+		{
+			name:         "garbled signature blocks",
+			suffix:       ".sig",
+			policy:       VerifyIfPresent,
+			key:          &signingKey.PublicKey,
+			companion:    "not base64!",
+			wantErr:      true,
+			wantSigError: true,
+		},
+		{
+			name:         "missing signature blocks once a key is configured",
+			suffix:       ".sig",
+			policy:       VerifyIfPresent,
+			key:          &signingKey.PublicKey,
+			wantErr:      true,
+			wantSigError: true,
+		},
+		{
+			name:   "missing signature warns",
+			suffix: ".sig",
+			policy: VerifyWarn,
+			key:    &signingKey.PublicKey,
+		},
+		{
+			name:         "missing signature blocks",
+			suffix:       ".sig",
+			policy:       VerifyRequire,
+			key:          &signingKey.PublicKey,
+			wantErr:      true,
+			wantSigError: true,
+		},
+		{
+			name:   "no key configured",
+			suffix: ".sig",
+			policy: VerifyIfPresent,
+		},
+		{
+			name:         "no key configured but required",
+			suffix:       ".sig",
+			policy:       VerifyRequire,
+			wantErr:      true,
+			wantSigError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(
+				func(rw http.ResponseWriter, req *http.Request) {
+					if tt.companion == "" || req.URL.Path != "/hello.tar.gz"+tt.suffix {
+						rw.WriteHeader(http.StatusNotFound)
+						return
+					}
+					_, _ = rw.Write([]byte(tt.companion))
+				},
+			))
+			defer server.Close()
+
+			f, err := download.MakeBasicFileFetcher(t.TempDir(), server.Client(), nil)
+			if err != nil {
+				t.Fatalf("could not create file fetcher: %s", err)
+			}
+
+			artifactPath := filepath.Join(t.TempDir(), "hello.tar.gz")
+			if err := ioutil.WriteFile(
+				artifactPath, []byte("hello"), os.ModePerm,
+			); err != nil {
+				t.Fatalf("could not write artifact: %s", err)
+			}
+
+			v := MakeCosignVerifier(tt.suffix, tt.policy)
+			err = v.Verify(
+				context.Background(), server.URL+"/hello.tar.gz", artifactPath, tt.key, f, ioutil.Discard,
+			)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var sigErr *kpkgerr.SignatureErr
+			if errors.As(err, &sigErr) != tt.wantSigError {
+				t.Errorf(
+					"Verify() error = %v, wantSigError %v", err, tt.wantSigError,
+				)
+			}
+		})
+	}
+}
+
+func TestLoadPublicKey(t *testing.T) {
+	k := makeTestEcdsaKey(t)
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	if err != nil {
+		t.Fatalf("could not marshal key: %s", err)
+	}
+	p := filepath.Join(t.TempDir(), "cosign.pub")
+	if err := ioutil.WriteFile(
+		p, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+		os.ModePerm,
+	); err != nil {
+		t.Fatalf("could not write key: %s", err)
+	}
+
+	got, err := LoadPublicKey(p)
+	if err != nil {
+		t.Fatalf("LoadPublicKey() error = %v, expected nil", err)
+	}
+	if !k.PublicKey.Equal(got) {
+		t.Errorf("LoadPublicKey() returned a different key")
+	}
+}
+
+func TestGithubReleaseTool_WithVerifiers(t *testing.T) {
+	base := MakeGithubReleaseTool("a", "b")
+	withVerifier := base.WithVerifiers(MakeCosignVerifier(".sig", VerifyWarn))
+	if len(base.Verifiers()) != 0 {
+		t.Errorf("expected base tool to have no verifiers")
+	}
+	if len(withVerifier.Verifiers()) != 1 {
+		t.Errorf("expected tool to have one verifier")
+	}
+}